@@ -1,17 +1,23 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
-	"github.com/tos-network/lab/internal/controller"
-	"github.com/tos-network/lab/internal/docker"
-	"github.com/tos-network/lab/internal/results"
-	"github.com/tos-network/lab/internal/sim"
+	"github.com/tos-network/labu/internal/backend"
+	"github.com/tos-network/labu/internal/controller"
+	"github.com/tos-network/labu/internal/docker"
+	"github.com/tos-network/labu/internal/log"
+	"github.com/tos-network/labu/internal/results"
+	"github.com/tos-network/labu/internal/sim"
 )
 
 func main() {
@@ -20,15 +26,26 @@ func main() {
 		clientNames    = flag.String("client", "", "comma-separated client names")
 		workspace      = flag.String("workspace", "./workspace", "workspace directory for logs/results")
 		vectorsDir     = flag.String("vectors", "", "vectors directory to mount into simulator")
+		vectorsCopy    = flag.Bool("vectors.copy", false, "inject vectors into the simulator with a tar copy instead of a bind mount")
 		simLimit       = flag.String("sim.limit", "", "regex to select suites/tests")
 		simParallel    = flag.Int("sim.parallelism", 1, "test concurrency")
 		simRandomSeed  = flag.Int64("sim.randomseed", 0, "random seed (0 means auto)")
 		simLogLevel    = flag.Int("sim.loglevel", 2, "simulator log level (0-5)")
 		simImage       = flag.String("sim.image", "", "override simulator image name")
 		clientImageMap = flag.String("client.images", "", "override client images (name=image,name=image)")
+		silent         = flag.Bool("silent", false, "suppress progress output")
+		abortTimeout   = flag.Duration("abort.timeout", 10*time.Second, "grace period to wait for simulator shutdown after Ctrl-C")
+		backendName    = flag.String("backend", "docker", "container runtime backend for client nodes: docker, podman, or kubernetes")
+		tokensFile     = flag.String("tokens-file", "", "path to a JSON bearer-token file ({token: {role, allowed_clients}}); empty disables auth")
+		tlsCert        = flag.String("tls-cert", "", "TLS certificate file for the control-plane API server")
+		tlsKey         = flag.String("tls-key", "", "TLS key file for the control-plane API server")
+		resultFormats  = flag.String("results.formats", "json", "comma-separated result output formats: json, junit, ndjson")
+		logFormat      = flag.String("log.format", "text", "labu's own log output format: text or json")
 	)
 	flag.Parse()
 
+	logger := log.New(log.LevelFromSimLogLevel(*simLogLevel), log.Format(*logFormat), os.Stderr)
+
 	if *simName == "" {
 		fmt.Fprintln(os.Stderr, "--sim is required")
 		os.Exit(2)
@@ -40,22 +57,32 @@ func main() {
 
 	ws, err := filepath.Abs(*workspace)
 	if err != nil {
-		log.Fatalf("workspace: %v", err)
+		logger.Fatalf("workspace: %v", err)
 	}
 	if err := os.MkdirAll(ws, 0o755); err != nil {
-		log.Fatalf("workspace mkdir: %v", err)
+		logger.Fatalf("workspace mkdir: %v", err)
 	}
 
 	clients := splitCSV(*clientNames)
 	if len(clients) == 0 {
-		log.Fatalf("no clients provided")
+		logger.Fatalf("no clients provided")
 	}
 
 	imageOverrides := parseImageOverrides(*clientImageMap)
 
-	dockerRunner := docker.NewRunner(ws)
-	ctrl := controller.New(ws, dockerRunner)
-	resWriter := results.NewWriter(ws)
+	dockerRunner := docker.NewRunner(ws, logger)
+	clientBackend, err := backend.New(*backendName, ws, logger)
+	if err != nil {
+		logger.Fatalf("backend: %v", err)
+	}
+	ctrl := controller.New(ws, clientBackend, logger)
+	resWriter, err := buildResultWriter(ws, *resultFormats)
+	if err != nil {
+		logger.Fatalf("results.formats: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	defer stop()
 
 	opts := sim.Options{
 		Simulator:       *simName,
@@ -63,6 +90,7 @@ func main() {
 		SimulatorImage:  *simImage,
 		ClientImages:    imageOverrides,
 		VectorsDir:      *vectorsDir,
+		VectorsCopy:     *vectorsCopy,
 		LimitPattern:    *simLimit,
 		Parallelism:     *simParallel,
 		RandomSeed:      *simRandomSeed,
@@ -71,11 +99,42 @@ func main() {
 		Controller:      ctrl,
 		ResultWriter:    resWriter,
 		DockerRunner:    dockerRunner,
+		Silent:          *silent,
+		AbortTimeout:    *abortTimeout,
+		TokensFile:      *tokensFile,
+		TLSCert:         *tlsCert,
+		TLSKey:          *tlsKey,
+		Logger:          logger,
+		StopNotify:      stop,
 	}
 
-	if err := sim.Run(opts); err != nil {
-		log.Fatalf("simulation failed: %v", err)
+	if err := sim.Run(ctx, opts); err != nil {
+		if errors.Is(err, context.Canceled) {
+			fmt.Fprintln(os.Stderr, "labu: aborted")
+			os.Exit(130)
+		}
+		logger.Fatalf("simulation failed: %v", err)
+	}
+}
+
+func buildResultWriter(workspace, formats string) (results.ResultWriter, error) {
+	var writers []results.ResultWriter
+	for _, f := range splitCSV(formats) {
+		switch f {
+		case "json":
+			writers = append(writers, results.NewWriter(workspace))
+		case "junit":
+			writers = append(writers, results.NewJUnitWriter(workspace))
+		case "ndjson":
+			writers = append(writers, results.NewNDJSONStreamWriter(workspace))
+		default:
+			return nil, fmt.Errorf("unknown format %q", f)
+		}
+	}
+	if len(writers) == 0 {
+		return nil, fmt.Errorf("no result formats selected")
 	}
+	return results.NewMultiWriter(writers...), nil
 }
 
 func splitCSV(s string) []string {
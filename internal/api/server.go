@@ -1,32 +1,264 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/gorilla/websocket"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/tos-network/labu/internal/auth"
 	"github.com/tos-network/labu/internal/controller"
+	"github.com/tos-network/labu/internal/docker"
+	"github.com/tos-network/labu/internal/errdefs"
+	"github.com/tos-network/labu/internal/logsink"
+	"github.com/tos-network/labu/internal/operations"
 	"github.com/tos-network/labu/internal/results"
 )
 
+// idempotencyHeader mirrors labusim's client-side IdempotencyHeader
+// constant (labusim can't be imported here without an import cycle, since
+// it's the client of this package).
+const idempotencyHeader = "X-Labu-Idempotency-Key"
+
+// execWSUpgrader upgrades GET .../exec/ws to a websocket. Origin checking is
+// left to whatever reverse proxy sits in front of labu; access control is
+// the bearer-token check authMiddleware applies to every route.
+var execWSUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// Stream IDs for the exec websocket's 1-byte frame prefix, a miniature
+// version of docker attach's multiplexing header.
+const (
+	streamStdin  byte = 0
+	streamStdout byte = 1
+	streamStderr byte = 2
+	streamExit   byte = 3
+)
+
 type Server struct {
 	ctrl   *controller.Controller
-	result *results.Writer
+	result results.ResultWriter
+	tokens *auth.Store
+	execs  *execRegistry
+	idem   *idempotencyCache
+}
+
+// New constructs a Server. tokens may be nil, which disables authentication
+// entirely (every request is treated as admin) — the loopback control-plane
+// server sim.Run starts for the simulator container relies on this. writer
+// may be a results.MultiWriter to emit several output formats per test case.
+func New(ctrl *controller.Controller, writer results.ResultWriter, tokens *auth.Store) *Server {
+	return &Server{ctrl: ctrl, result: writer, tokens: tokens, execs: newExecRegistry(), idem: newIdempotencyCache()}
+}
+
+// idempotencyCache dedupes create calls by the client's IdempotencyHeader
+// value: a retry after a timeout/5xx on a request that actually went
+// through server-side replays the first call's result instead of creating
+// a second suite/test/node. Concurrent callers sharing a key are coalesced
+// onto one call via group; once it completes, the result is kept for the
+// lifetime of the process so a later retry still replays it instead of
+// re-running fn.
+type idempotencyCache struct {
+	group   singleflight.Group
+	mu      sync.Mutex
+	results map[string]any
 }
 
-func New(ctrl *controller.Controller, writer *results.Writer) *Server {
-	return &Server{ctrl: ctrl, result: writer}
+func newIdempotencyCache() *idempotencyCache {
+	return &idempotencyCache{results: make(map[string]any)}
+}
+
+// do calls fn and returns its result, or replays a previous call's result if
+// key was already used. An empty key (no IdempotencyHeader sent) always
+// calls fn directly.
+func (c *idempotencyCache) do(key string, fn func() (any, error)) (any, error) {
+	if key == "" {
+		return fn()
+	}
+	c.mu.Lock()
+	if v, ok := c.results[key]; ok {
+		c.mu.Unlock()
+		return v, nil
+	}
+	c.mu.Unlock()
+
+	v, err, _ := c.group.Do(key, fn)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.results[key] = v
+	c.mu.Unlock()
+	return v, nil
+}
+
+// execRegistry tracks cancel funcs for in-flight streamed execs so a
+// DELETE .../exec/{execID} arriving on a separate HTTP request can abort
+// one started by handleExecStream.
+type execRegistry struct {
+	mu      sync.Mutex
+	seq     int
+	cancels map[string]context.CancelFunc
+}
+
+func newExecRegistry() *execRegistry {
+	return &execRegistry{cancels: make(map[string]context.CancelFunc)}
+}
+
+// register allocates an exec ID and stores cancel under it.
+func (r *execRegistry) register(cancel context.CancelFunc) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	id := fmt.Sprintf("exec-%d", r.seq)
+	r.cancels[id] = cancel
+	return id
+}
+
+func (r *execRegistry) unregister(id string) {
+	r.mu.Lock()
+	delete(r.cancels, id)
+	r.mu.Unlock()
+}
+
+// cancel fires id's cancel func, reporting whether id was still tracked.
+func (r *execRegistry) cancel(id string) bool {
+	r.mu.Lock()
+	cancel, ok := r.cancels[id]
+	r.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
 }
 
 func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/clients", s.handleClients)
+	mux.HandleFunc("/clients/", s.handleClientsSub)
 	mux.HandleFunc("/testsuite", s.handleSuite)
 	mux.HandleFunc("/testsuite/", s.handleSuiteSub)
-	return mux
+	mux.HandleFunc("/logs/tail", s.handleLogsTail)
+	mux.HandleFunc("/operations", s.handleOperations)
+	mux.HandleFunc("/operations/", s.handleOperationsSub)
+	mux.HandleFunc("/events", s.handleEvents)
+	mux.HandleFunc("/gc", s.handleGC)
+	return s.authMiddleware(mux)
+}
+
+type authTokenKey struct{}
+
+// authContext is what authMiddleware attaches to a request's context: the
+// raw bearer token string (used to compare against a Suite's CreatorToken)
+// plus its resolved role/allowlist.
+type authContext struct {
+	Raw   string
+	Token auth.Token
+}
+
+// authMiddleware enforces the bearer-token role model before any route
+// handler runs. It is a no-op when the server was built with a nil token
+// store. requestToken retrieves the validated auth.Token a handler needs to
+// apply finer-grained checks (e.g. a client allowlist on node launch).
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.tokens == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := r.Header.Get("Authorization")
+		const prefix = "Bearer "
+		if !strings.HasPrefix(header, prefix) {
+			writeErrorCode(w, http.StatusUnauthorized, "UNAUTHORIZED", "missing bearer token")
+			return
+		}
+		raw := strings.TrimPrefix(header, prefix)
+		tok, ok := s.tokens.Lookup(raw)
+		if !ok {
+			writeErrorCode(w, http.StatusUnauthorized, "UNAUTHORIZED", "invalid token")
+			return
+		}
+
+		if tok.Role == auth.RoleReadonly && r.Method != http.MethodGet {
+			writeErrorCode(w, http.StatusForbidden, "FORBIDDEN", "readonly token cannot perform this request")
+			return
+		}
+
+		if tok.Role != auth.RoleAdmin && isAdminOnlyRoute(r.Method, r.URL.Path) {
+			writeErrorCode(w, http.StatusForbidden, "FORBIDDEN", "this request requires an admin token")
+			return
+		}
+
+		if tok.Role == auth.RoleRunner && r.Method != http.MethodGet && r.URL.Path != "/testsuite" {
+			suiteID, ok := suiteIDFromPath(r.URL.Path)
+			if !ok {
+				writeErrorCode(w, http.StatusForbidden, "FORBIDDEN", "runner token may only act on a suite it created")
+				return
+			}
+			owner, exists := s.ctrl.SuiteCreatorToken(suiteID)
+			if exists && owner != raw {
+				writeErrorCode(w, http.StatusForbidden, "FORBIDDEN", "token does not own this suite")
+				return
+			}
+		}
+
+		ctx := context.WithValue(r.Context(), authTokenKey{}, authContext{Raw: raw, Token: tok})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// isAdminOnlyRoute reports whether method/path names one of the handful of
+// mutating endpoints that act across every suite rather than on one a
+// runner token could own: GC sweeps every untracked container regardless of
+// which suite created it, cancelling an operation can stop another suite's
+// node launch or image build (operations aren't tagged with an owning
+// suite), and a client image is a shared resource rebuilt for every suite
+// that uses it. These require an admin token; a runner token, even one
+// scoped to a suite it created, may not call them.
+func isAdminOnlyRoute(method, path string) bool {
+	if method == http.MethodPost && path == "/gc" {
+		return true
+	}
+	if method == http.MethodDelete && strings.HasPrefix(path, "/operations/") {
+		return true
+	}
+	if method == http.MethodPost && strings.HasPrefix(path, "/clients/") && strings.HasSuffix(path, "/image/rebuild") {
+		return true
+	}
+	return false
+}
+
+// suiteIDFromPath extracts the suite ID from a /testsuite/{id}/... path, if
+// present.
+func suiteIDFromPath(path string) (int, bool) {
+	path = strings.TrimPrefix(path, "/testsuite/")
+	parts := strings.SplitN(path, "/", 2)
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// requestToken returns the authContext attached by authMiddleware, or
+// ok=false when auth is disabled (nil token store).
+func requestToken(r *http.Request) (authContext, bool) {
+	ac, ok := r.Context().Value(authTokenKey{}).(authContext)
+	return ac, ok
 }
 
 func (s *Server) handleClients(w http.ResponseWriter, r *http.Request) {
@@ -38,6 +270,193 @@ func (s *Server) handleClients(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, clients)
 }
 
+// handleClientsSub serves GET /clients/{name}/image and
+// POST /clients/{name}/image/rebuild.
+func (s *Server) handleClientsSub(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/clients/")
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 || parts[1] != "image" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	name := parts[0]
+
+	if len(parts) == 2 {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		info, err := s.ctrl.ClientImage(name)
+		if err != nil {
+			writeErr(w, err)
+			return
+		}
+		writeJSON(w, info)
+		return
+	}
+
+	if len(parts) == 3 && parts[2] == "rebuild" {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		op, err := s.ctrl.RebuildClientImage(name)
+		if err != nil {
+			writeErr(w, err)
+			return
+		}
+		writeJSONStatus(w, http.StatusAccepted, op.Snapshot())
+		return
+	}
+
+	writeError(w, http.StatusNotFound, "not found")
+}
+
+// handleLogsTail serves recent simulator log lines from the Controller's
+// in-memory ring buffer. Query params: container (optional filter), level
+// (minimum severity name, default "trace"), limit (max lines, default 200).
+func (s *Server) handleLogsTail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	minSeverity := logsink.SeverityTrace
+	if lvl := r.URL.Query().Get("level"); lvl != "" {
+		sev, ok := logsink.ParseSeverity(lvl)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalid level")
+			return
+		}
+		minSeverity = sev
+	}
+	limit := 200
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = n
+	}
+	lines := s.ctrl.TailLogs(r.URL.Query().Get("container"), minSeverity, limit)
+	writeJSON(w, lines)
+}
+
+// handleOperations serves GET /operations, listing every tracked operation.
+func (s *Server) handleOperations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	ops := s.ctrl.Operations().List()
+	out := make([]operations.Operation, 0, len(ops))
+	for _, op := range ops {
+		out = append(out, op.Snapshot())
+	}
+	writeJSON(w, out)
+}
+
+// handleOperationsSub serves GET/DELETE /operations/{id} and
+// GET /operations/{id}/wait?timeout=.
+func (s *Server) handleOperationsSub(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/operations/")
+	parts := strings.Split(path, "/")
+	if parts[0] == "" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	op, ok := s.ctrl.Operations().Get(parts[0])
+	if !ok {
+		writeErr(w, errdefs.NotFound(fmt.Errorf("operation %q not found", parts[0])))
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "wait" {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		timeout := 30 * time.Second
+		if raw := r.URL.Query().Get("timeout"); raw != "" {
+			if d, err := time.ParseDuration(raw); err == nil {
+				timeout = d
+			}
+		}
+		op.Wait(timeout)
+		writeJSON(w, op.Snapshot())
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, op.Snapshot())
+	case http.MethodDelete:
+		if !s.ctrl.Operations().CancelOp(op.ID) {
+			writeErr(w, errdefs.InvalidParameter(fmt.Errorf("operation %q cannot be cancelled", op.ID)))
+			return
+		}
+		writeJSON(w, op.Snapshot())
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleEvents serves GET /events as a server-sent-events stream of
+// operation lifecycle transitions.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	ch, unsubscribe := s.ctrl.Operations().Bus.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// handleGC serves POST /gc, removing containers from prior crashed runs
+// that carry a labu.suite label but aren't tracked by this controller
+// instance, and reporting which ones it removed.
+func (s *Server) handleGC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	removed, err := s.ctrl.GC()
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"removed": removed})
+}
+
 func (s *Server) handleSuite(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodPost {
 		var req controller.SuiteCreate
@@ -45,8 +464,14 @@ func (s *Server) handleSuite(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusBadRequest, "invalid json")
 			return
 		}
-		id := s.ctrl.CreateSuite(req)
-		writeJSON(w, id)
+		creatorToken := ""
+		if ac, ok := requestToken(r); ok {
+			creatorToken = ac.Raw
+		}
+		v, _ := s.idem.do(r.Header.Get(idempotencyHeader), func() (any, error) {
+			return s.ctrl.CreateSuite(req, creatorToken), nil
+		})
+		writeJSON(w, v.(int))
 		return
 	}
 	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -68,7 +493,7 @@ func (s *Server) handleSuiteSub(w http.ResponseWriter, r *http.Request) {
 	if len(parts) == 1 {
 		if r.Method == http.MethodDelete {
 			if err := s.ctrl.EndSuite(suiteID); err != nil {
-				writeError(w, http.StatusNotFound, err.Error())
+				writeErr(w, err)
 				return
 			}
 			writeJSON(w, "ok")
@@ -85,6 +510,9 @@ func (s *Server) handleSuiteSub(w http.ResponseWriter, r *http.Request) {
 	case "network":
 		s.handleNetwork(w, r, suiteID, parts[2:])
 		return
+	case "volume":
+		s.handleVolume(w, r, suiteID, parts[2:])
+		return
 	default:
 		writeError(w, http.StatusNotFound, "not found")
 		return
@@ -99,12 +527,14 @@ func (s *Server) handleTest(w http.ResponseWriter, r *http.Request, suiteID int,
 				writeError(w, http.StatusBadRequest, "invalid json")
 				return
 			}
-			id, err := s.ctrl.CreateTest(suiteID, req)
+			v, err := s.idem.do(r.Header.Get(idempotencyHeader), func() (any, error) {
+				return s.ctrl.CreateTest(suiteID, req)
+			})
 			if err != nil {
-				writeError(w, http.StatusNotFound, err.Error())
+				writeErr(w, err)
 				return
 			}
-			writeJSON(w, id)
+			writeJSON(w, v.(int))
 			return
 		}
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -124,10 +554,14 @@ func (s *Server) handleTest(w http.ResponseWriter, r *http.Request, suiteID int,
 				writeError(w, http.StatusBadRequest, "invalid json")
 				return
 			}
-			if err := s.ctrl.EndTest(suiteID, testID, req); err != nil {
-				writeError(w, http.StatusNotFound, err.Error())
+			tc, err := s.ctrl.EndTest(suiteID, testID, req)
+			if err != nil {
+				writeErr(w, err)
 				return
 			}
+			if err := s.result.WriteTestCase(suiteID, tc); err != nil {
+				log.Printf("results write error: %v", err)
+			}
 			if err := s.ctrl.SaveResults(s.result); err != nil {
 				log.Printf("results write error: %v", err)
 			}
@@ -156,12 +590,27 @@ func (s *Server) handleNode(w http.ResponseWriter, r *http.Request, suiteID, tes
 				writeError(w, http.StatusBadRequest, err.Error())
 				return
 			}
-			info, err := s.ctrl.LaunchNode(suiteID, testID, cfg, files)
+			if ac, ok := requestToken(r); ok && !ac.Token.AllowsClient(cfg.Client) {
+				writeErrorCode(w, http.StatusForbidden, "FORBIDDEN", "token is not allowed to launch this client")
+				return
+			}
+			v, err := s.idem.do(r.Header.Get(idempotencyHeader), func() (any, error) {
+				return s.ctrl.LaunchNode(suiteID, testID, cfg, files)
+			})
 			if err != nil {
-				writeError(w, http.StatusInternalServerError, err.Error())
+				writeErr(w, err)
 				return
 			}
-			writeJSON(w, info)
+			writeJSON(w, v)
+			return
+		}
+		if r.Method == http.MethodGet {
+			nodes, err := s.ctrl.ListNodes(suiteID, testID, r.URL.Query().Get("label"))
+			if err != nil {
+				writeErr(w, err)
+				return
+			}
+			writeJSON(w, nodes)
 			return
 		}
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -192,6 +641,27 @@ func (s *Server) handleNode(w http.ResponseWriter, r *http.Request, suiteID, tes
 	}
 
 	if parts[1] == "exec" {
+		if len(parts) == 3 && parts[2] == "ws" {
+			s.handleExecWS(w, r, containerID)
+			return
+		}
+		if len(parts) == 3 && parts[2] == "stream" {
+			s.handleExecStream(w, r, containerID)
+			return
+		}
+		if len(parts) == 3 {
+			// .../exec/{execID}: abort a still-running handleExecStream call.
+			if r.Method != http.MethodDelete {
+				writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+				return
+			}
+			if !s.execs.cancel(parts[2]) {
+				writeErr(w, errdefs.NotFound(fmt.Errorf("exec %q not found", parts[2])))
+				return
+			}
+			writeJSON(w, "ok")
+			return
+		}
 		if r.Method != http.MethodPost {
 			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 			return
@@ -216,9 +686,200 @@ func (s *Server) handleNode(w http.ResponseWriter, r *http.Request, suiteID, tes
 		return
 	}
 
+	if parts[1] == "logs" {
+		s.handleNodeLogs(w, r, containerID)
+		return
+	}
+
 	writeError(w, http.StatusNotFound, "not found")
 }
 
+// handleExecWS upgrades GET .../node/{cid}/exec/ws to a websocket that
+// multiplexes stdin (client->server) and stdout/stderr (server->client) as
+// frames prefixed with a 1-byte stream ID. Closing the websocket or the
+// request context kills the underlying exec. When the exec finishes on its
+// own, a final streamExit frame carries its exit code (and error, if any)
+// as JSON, and the server closes the connection itself rather than leaving
+// it open until the client notices.
+func (s *Server) handleExecWS(w http.ResponseWriter, r *http.Request, containerID string) {
+	cmd := r.URL.Query()["cmd"]
+	if len(cmd) == 0 {
+		writeError(w, http.StatusBadRequest, "missing cmd query param")
+		return
+	}
+
+	conn, err := execWSUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	var writeMu sync.Mutex
+	writeFrame := func(streamID byte, p []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return conn.WriteMessage(websocket.BinaryMessage, append([]byte{streamID}, p...))
+	}
+
+	stdinR, stdinW := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer stdinR.Close()
+		code, err := s.ctrl.ExecStream(ctx, containerID, cmd,
+			stdinR,
+			frameWriter{id: streamStdout, write: writeFrame},
+			frameWriter{id: streamStderr, write: writeFrame})
+		final := map[string]interface{}{"exitCode": code}
+		if err != nil && ctx.Err() == nil {
+			final["error"] = err.Error()
+		}
+		if data, marshalErr := json.Marshal(final); marshalErr == nil {
+			_ = writeFrame(streamExit, data)
+		}
+		writeMu.Lock()
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		conn.Close()
+		writeMu.Unlock()
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		if len(data) < 1 || data[0] != streamStdin {
+			continue
+		}
+		if _, err := stdinW.Write(data[1:]); err != nil {
+			break
+		}
+	}
+	stdinW.Close()
+	cancel()
+	<-done
+}
+
+// frameWriter adapts a single multiplexed stream onto the shared websocket
+// connection, prefixing every write with its stream ID byte.
+type frameWriter struct {
+	id    byte
+	write func(id byte, p []byte) error
+}
+
+func (f frameWriter) Write(p []byte) (int, error) {
+	if err := f.write(f.id, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// handleExecStream serves POST .../node/{cid}/exec/stream, running cmd with
+// stdout/stderr relayed live as chunked NDJSON lines instead of buffered
+// until exit, so a long-running command (e.g. a node's own run command)
+// surfaces logs to the test writer as they happen. The X-Labu-Exec-Id
+// response header carries the ID a separate DELETE .../exec/{execID} needs
+// to abort it mid-run.
+func (s *Server) handleExecStream(w http.ResponseWriter, r *http.Request, containerID string) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var payload struct {
+		Command []string `json:"command"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	execID := s.execs.register(cancel)
+	defer s.execs.unregister(execID)
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("X-Labu-Exec-Id", execID)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	fw := flushWriter{w: w, flusher: flusher}
+	enc := json.NewEncoder(fw)
+	stdout := ndjsonWriter{stream: "stdout", enc: enc}
+	stderr := ndjsonWriter{stream: "stderr", enc: enc}
+
+	code, err := s.ctrl.ExecStream(ctx, containerID, payload.Command, nil, stdout, stderr)
+	final := map[string]interface{}{"exitCode": code}
+	if err != nil && ctx.Err() == nil {
+		final["error"] = err.Error()
+	}
+	_ = enc.Encode(final)
+}
+
+// ndjsonWriter turns every Write into one NDJSON exec event line tagged with
+// its stream name, for handleExecStream's live log relay.
+type ndjsonWriter struct {
+	stream string
+	enc    *json.Encoder
+}
+
+func (w ndjsonWriter) Write(p []byte) (int, error) {
+	if err := w.enc.Encode(map[string]interface{}{"stream": w.stream, "data": p}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// handleNodeLogs serves GET .../node/{cid}/logs as a chunked stream of
+// container logs, optionally following new output and filtered by since/
+// tail, mirroring "docker logs" flags.
+func (s *Server) handleNodeLogs(w http.ResponseWriter, r *http.Request, containerID string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	opts := docker.LogsOptions{
+		Follow: r.URL.Query().Get("follow") == "1",
+		Since:  r.URL.Query().Get("since"),
+		Tail:   r.URL.Query().Get("tail"),
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	if err := s.ctrl.LogsStream(r.Context(), containerID, opts, flushWriter{w: w, flusher: flusher}); err != nil {
+		log.Printf("logs stream for %s: %v", containerID, err)
+	}
+}
+
+// flushWriter flushes after every write so a chunked HTTP response delivers
+// log lines as they arrive instead of buffering until close.
+type flushWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func (f flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	f.flusher.Flush()
+	return n, err
+}
+
 func (s *Server) handleNetwork(w http.ResponseWriter, r *http.Request, suiteID int, parts []string) {
 	if len(parts) == 0 {
 		writeError(w, http.StatusNotFound, "not found")
@@ -227,11 +888,21 @@ func (s *Server) handleNetwork(w http.ResponseWriter, r *http.Request, suiteID i
 	netName := parts[0]
 	if len(parts) == 1 {
 		if r.Method == http.MethodPost {
-			if err := s.ctrl.CreateNetwork(netName); err != nil {
-				writeError(w, http.StatusInternalServerError, err.Error())
-				return
+			var netOpts docker.NetworkOptions
+			if r.ContentLength != 0 {
+				var body struct {
+					Subnet  string `json:"subnet"`
+					Gateway string `json:"gateway"`
+					Driver  string `json:"driver"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil && err != io.EOF {
+					writeError(w, http.StatusBadRequest, "invalid json")
+					return
+				}
+				netOpts = docker.NetworkOptions{Subnet: body.Subnet, Gateway: body.Gateway, Driver: body.Driver}
 			}
-			writeJSON(w, "ok")
+			op := s.ctrl.CreateNetworkAsync(netName, netOpts)
+			writeJSONStatus(w, http.StatusAccepted, op.Snapshot())
 			return
 		}
 		if r.Method == http.MethodDelete {
@@ -275,7 +946,75 @@ func (s *Server) handleNetwork(w http.ResponseWriter, r *http.Request, suiteID i
 	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 }
 
-func (s *Server) Start(addr string) error {
+// handleVolume serves POST/GET /testsuite/{id}/volume, DELETE
+// /testsuite/{id}/volume/{name}, and POST
+// /testsuite/{id}/volume/{name}/snapshot.
+func (s *Server) handleVolume(w http.ResponseWriter, r *http.Request, suiteID int, parts []string) {
+	if len(parts) == 0 {
+		switch r.Method {
+		case http.MethodPost:
+			var req controller.VolumeCreate
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid json")
+				return
+			}
+			vol, err := s.ctrl.CreateVolume(suiteID, req)
+			if err != nil {
+				writeErr(w, err)
+				return
+			}
+			writeJSON(w, vol)
+		case http.MethodGet:
+			vols, err := s.ctrl.ListVolumes(suiteID)
+			if err != nil {
+				writeErr(w, err)
+				return
+			}
+			writeJSON(w, vols)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	name := parts[0]
+	if len(parts) == 1 {
+		if r.Method != http.MethodDelete {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		if err := s.ctrl.RemoveVolume(suiteID, name); err != nil {
+			writeErr(w, err)
+			return
+		}
+		writeJSON(w, "ok")
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "snapshot" {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		path, err := s.ctrl.SnapshotVolume(suiteID, name)
+		if err != nil {
+			writeErr(w, err)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"path": path})
+		return
+	}
+
+	writeError(w, http.StatusNotFound, "not found")
+}
+
+// Start serves the API on addr. When both tlsCert and tlsKey are set it
+// serves over TLS, which matters here because bearer tokens are sent as
+// plain Authorization headers.
+func (s *Server) Start(addr, tlsCert, tlsKey string) error {
+	if tlsCert != "" && tlsKey != "" {
+		return http.ListenAndServeTLS(addr, tlsCert, tlsKey, s.Handler())
+	}
 	return http.ListenAndServe(addr, s.Handler())
 }
 
@@ -287,8 +1026,57 @@ func writeJSON(w http.ResponseWriter, v interface{}) {
 	}
 }
 
+func writeJSONStatus(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeErr maps err to an HTTP status and machine-readable code by checking
+// it against the errdefs interfaces, falling back to 500/"SYSTEM" for plain
+// errors that predate the typed error rollout.
+func writeErr(w http.ResponseWriter, err error) {
+	var notFound errdefs.ErrNotFound
+	var invalid errdefs.ErrInvalidParameter
+	var conflict errdefs.ErrConflict
+	var unavailable errdefs.ErrUnavailable
+	switch {
+	case errors.As(err, &notFound):
+		writeErrorCode(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+	case errors.As(err, &invalid):
+		writeErrorCode(w, http.StatusBadRequest, "INVALID_PARAMETER", err.Error())
+	case errors.As(err, &conflict):
+		writeErrorCode(w, http.StatusConflict, "CONFLICT", err.Error())
+	case errors.As(err, &unavailable):
+		writeErrorCode(w, http.StatusServiceUnavailable, "UNAVAILABLE", err.Error())
+	default:
+		writeErrorCode(w, http.StatusInternalServerError, "SYSTEM", err.Error())
+	}
+}
+
 func writeError(w http.ResponseWriter, code int, msg string) {
+	writeErrorCode(w, code, codeForStatus(code), msg)
+}
+
+func codeForStatus(code int) string {
+	switch code {
+	case http.StatusBadRequest:
+		return "INVALID_PARAMETER"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusConflict:
+		return "CONFLICT"
+	case http.StatusServiceUnavailable:
+		return "UNAVAILABLE"
+	case http.StatusMethodNotAllowed:
+		return "METHOD_NOT_ALLOWED"
+	default:
+		return "SYSTEM"
+	}
+}
+
+func writeErrorCode(w http.ResponseWriter, code int, tag, msg string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
-	_, _ = fmt.Fprintf(w, "{\"error\":%q}", msg)
+	_, _ = fmt.Fprintf(w, "{\"error\":%q,\"code\":%q}", msg, tag)
 }
@@ -0,0 +1,169 @@
+// Package archive builds and extracts the POSIX tar streams labu hands to
+// the Docker Engine API's /containers/{id}/archive endpoint (via
+// docker.Runner's CopyToContainer/CopyFromContainer), so callers work with
+// plain directories instead of constructing tar headers themselves.
+package archive
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// PackOptions configures which files under a directory Pack includes.
+type PackOptions struct {
+	// Includes, if non-empty, limits packed entries to files whose path
+	// relative to dir matches one of these filepath.Match patterns. An
+	// empty Includes packs every regular file, directory, and symlink
+	// under dir.
+	Includes []string
+}
+
+// Pack walks dir and streams a tar archive of its contents, preserving mode
+// and, on platforms that support it, owner/group. It returns immediately
+// with the read end of a pipe; the walk runs in a goroutine and any error
+// it hits is delivered through the pipe to the next Read call.
+func Pack(dir string, opts PackOptions) (io.Reader, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(pack(dir, opts, pw))
+	}()
+	return pr, nil
+}
+
+func pack(dir string, opts PackOptions, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if !matches(rel, opts.Includes) {
+			return nil
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err = os.Readlink(path)
+			if err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		hdr.Uid, hdr.Gid = lchownIDs(info)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			if _, err := io.Copy(tw, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// matches reports whether rel should be included given patterns. No
+// patterns means everything matches.
+func matches(rel string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Unpack extracts a tar stream produced by Pack (or by the Docker Engine
+// API's CopyFromContainer) into destDir, creating parent directories as
+// needed and restoring ownership with os.Lchown rather than syscall.Lchown
+// so this package compiles on darwin and windows. Ownership restoration is
+// best-effort: running unprivileged, os.Lchown will fail for any uid/gid
+// other than the caller's, and that failure is ignored.
+func Unpack(r io.Reader, destDir string) error {
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		return err
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target, err := safeJoin(destDir, hdr.Name)
+		if err != nil {
+			return err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(hdr.Mode)); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			_ = os.Remove(target)
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+		default:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(hdr.Mode))
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, tr); err != nil {
+				out.Close()
+				return err
+			}
+			out.Close()
+		}
+		_ = os.Lchown(target, hdr.Uid, hdr.Gid)
+	}
+}
+
+// safeJoin joins dir and name, rejecting archive entries that would escape
+// dir via ".." path components (a tar slip).
+func safeJoin(dir, name string) (string, error) {
+	target := filepath.Join(dir, name)
+	if !filepathHasPrefix(target, dir) {
+		return "", fmt.Errorf("archive entry escapes destination: %s", name)
+	}
+	return target, nil
+}
+
+func filepathHasPrefix(target, dir string) bool {
+	clean := filepath.Clean(dir)
+	return target == clean || len(target) > len(clean) && target[:len(clean)+1] == clean+string(os.PathSeparator)
+}
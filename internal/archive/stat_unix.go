@@ -0,0 +1,18 @@
+//go:build !windows
+
+package archive
+
+import (
+	"os"
+	"syscall"
+)
+
+// lchownIDs reads the owning uid/gid off info's underlying syscall.Stat_t so
+// Pack can record them in the tar header.
+func lchownIDs(info os.FileInfo) (uid, gid int) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0
+	}
+	return int(st.Uid), int(st.Gid)
+}
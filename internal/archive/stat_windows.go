@@ -0,0 +1,11 @@
+//go:build windows
+
+package archive
+
+import "os"
+
+// lchownIDs has no equivalent on windows, which has no uid/gid concept;
+// Pack records zero for both.
+func lchownIDs(info os.FileInfo) (uid, gid int) {
+	return 0, 0
+}
@@ -0,0 +1,73 @@
+// Package auth validates bearer tokens against a static token file and
+// expresses the three-tier role model the API enforces: admin (everything),
+// runner (suite/test/node CRUD scoped to suites they created), and readonly
+// (GET only).
+package auth
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// Role is the permission tier granted to a bearer token.
+type Role string
+
+const (
+	RoleAdmin    Role = "admin"
+	RoleRunner   Role = "runner"
+	RoleReadonly Role = "readonly"
+)
+
+// Token is one entry from the --tokens-file, keyed there by the bearer
+// token string itself. AllowedClients, when non-empty, restricts which
+// clients the token may launch nodes for.
+type Token struct {
+	Role           Role     `json:"role"`
+	AllowedClients []string `json:"allowed_clients"`
+}
+
+// AllowsClient reports whether t may launch a node for the named client.
+// An empty AllowedClients means all clients are permitted.
+func (t Token) AllowsClient(name string) bool {
+	if len(t.AllowedClients) == 0 {
+		return true
+	}
+	for _, c := range t.AllowedClients {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Store is the in-memory token -> Token mapping loaded from a tokens file.
+type Store struct {
+	tokens map[string]Token
+}
+
+// Load reads a tokens file shaped as:
+//
+//	{"<token>": {"role": "admin|runner|readonly", "allowed_clients": ["geth"]}}
+func Load(path string) (*Store, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var tokens map[string]Token
+	if err := json.Unmarshal(b, &tokens); err != nil {
+		return nil, err
+	}
+	return &Store{tokens: tokens}, nil
+}
+
+// Lookup returns the Token registered for a raw bearer token string. A nil
+// Store (no --tokens-file configured) never finds a token, the same result
+// as an unknown token; callers that need to tell "auth disabled" from "bad
+// credentials" check for a nil Store themselves.
+func (s *Store) Lookup(token string) (Token, bool) {
+	if s == nil {
+		return Token{}, false
+	}
+	t, ok := s.tokens[token]
+	return t, ok
+}
@@ -0,0 +1,66 @@
+// Package backend abstracts the container runtime the Controller launches
+// client nodes through, so labu can run against whatever's available in a
+// given environment (a Docker daemon, rootless Podman, a Kubernetes
+// cluster) instead of hard-wiring docker.Runner everywhere.
+package backend
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/tos-network/labu/internal/docker"
+	"github.com/tos-network/labu/internal/log"
+)
+
+// Backend is the set of container runtime operations the Controller needs
+// to launch and manage client nodes. docker.Runner already implements this
+// shape; other runtimes (Podman, Kubernetes) provide their own.
+type Backend interface {
+	Build(ctxDir, dockerfile, tag string, buildArgs map[string]string) (string, error)
+	ImageInspect(tag string) (bool, error)
+	Run(cfg docker.RunConfig) (string, error)
+	Exec(containerID string, cmd []string) (int, string, string, error)
+	ExecStream(ctx context.Context, containerID string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) (int, error)
+	LogsStream(ctx context.Context, containerID string, opts docker.LogsOptions, w io.Writer) error
+	Stop(containerID string) error
+	Remove(containerID string) error
+	Wait(containerID string) (int, error)
+	Logs(containerID string) (string, error)
+	LogsFollow(containerID string) (io.ReadCloser, error)
+	ListContainers(labelKey string) ([]string, error)
+	CreateNetwork(name string) error
+	CreateNetworkOpts(name string, opts docker.NetworkOptions) error
+	CreateInternalNetwork(name string) error
+	CreateEgressAllowlistNetwork(name string, allowCIDRs []string) error
+	RemoveNetwork(name string) error
+	ConnectNetwork(name, containerID string) error
+	ConnectNetworkEndpoint(name, containerID string, ep docker.NetworkEndpoint) error
+	DisconnectNetwork(name, containerID string) error
+	InspectIP(network, containerID string) (string, error)
+	CreateVolume(name string, opts docker.VolumeOptions) error
+	RemoveVolume(name string) error
+	SnapshotVolume(name, destDir string) error
+}
+
+var _ Backend = (*docker.Runner)(nil)
+var _ Backend = (*PodmanRunner)(nil)
+var _ Backend = (*KubernetesRunner)(nil)
+
+// New constructs the Backend named by kind ("docker", "podman", or
+// "kubernetes") rooted at workspace. kubernetes is accepted so callers can
+// select it, but every method on the returned Backend currently returns an
+// error: see KubernetesRunner for what's left to implement. logger may be
+// nil, in which case the returned Backend logs nothing.
+func New(kind, workspace string, logger *log.Logger) (Backend, error) {
+	switch kind {
+	case "", "docker":
+		return docker.NewRunner(workspace, logger), nil
+	case "podman":
+		return NewPodmanRunner(workspace, logger), nil
+	case "kubernetes":
+		return NewKubernetesRunner(workspace, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q", kind)
+	}
+}
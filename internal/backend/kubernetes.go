@@ -0,0 +1,130 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/tos-network/labu/internal/docker"
+	"github.com/tos-network/labu/internal/log"
+)
+
+// ErrKubernetesUnimplemented is returned by every KubernetesRunner method.
+// The type exists so the Backend interface is known to be satisfiable by a
+// Kubernetes-backed implementation (a "node" mapping to a Pod, a "network"
+// to a NetworkPolicy/namespace); wiring it up to a real client-go clientset
+// is left to whoever needs it.
+var ErrKubernetesUnimplemented = errors.New("kubernetes backend not implemented")
+
+// KubernetesRunner is a stub Backend for running labu against a Kubernetes
+// cluster instead of a local container runtime.
+type KubernetesRunner struct {
+	workspace string
+	logger    *log.Logger
+}
+
+// NewKubernetesRunner constructs a KubernetesRunner rooted at workspace.
+// logger is accepted for parity with the other backends' constructors but
+// unused until this stub grows a real client-go implementation.
+func NewKubernetesRunner(workspace string, logger *log.Logger) *KubernetesRunner {
+	if logger == nil {
+		logger = log.Discard()
+	}
+	return &KubernetesRunner{workspace: workspace, logger: logger}
+}
+
+func (r *KubernetesRunner) Build(ctxDir, dockerfile, tag string, buildArgs map[string]string) (string, error) {
+	return "", ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) ImageInspect(tag string) (bool, error) {
+	return false, ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) Run(cfg docker.RunConfig) (string, error) {
+	return "", ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) Exec(containerID string, cmd []string) (int, string, string, error) {
+	return 0, "", "", ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) ExecStream(ctx context.Context, containerID string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	return 0, ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) LogsStream(ctx context.Context, containerID string, opts docker.LogsOptions, w io.Writer) error {
+	return ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) Stop(containerID string) error {
+	return ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) Remove(containerID string) error {
+	return ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) Wait(containerID string) (int, error) {
+	return 0, ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) Logs(containerID string) (string, error) {
+	return "", ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) LogsFollow(containerID string) (io.ReadCloser, error) {
+	return nil, ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) ListContainers(labelKey string) ([]string, error) {
+	return nil, ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) CreateNetwork(name string) error {
+	return ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) CreateNetworkOpts(name string, opts docker.NetworkOptions) error {
+	return ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) CreateInternalNetwork(name string) error {
+	return ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) CreateEgressAllowlistNetwork(name string, allowCIDRs []string) error {
+	return ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) RemoveNetwork(name string) error {
+	return ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) ConnectNetwork(name, containerID string) error {
+	return ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) ConnectNetworkEndpoint(name, containerID string, ep docker.NetworkEndpoint) error {
+	return ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) DisconnectNetwork(name, containerID string) error {
+	return ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) InspectIP(network, containerID string) (string, error) {
+	return "", ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) CreateVolume(name string, opts docker.VolumeOptions) error {
+	return ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) RemoveVolume(name string) error {
+	return ErrKubernetesUnimplemented
+}
+
+func (r *KubernetesRunner) SnapshotVolume(name, destDir string) error {
+	return ErrKubernetesUnimplemented
+}
@@ -0,0 +1,488 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/tos-network/labu/internal/docker"
+	"github.com/tos-network/labu/internal/log"
+)
+
+// PodmanRunner shells out to the podman CLI with the same semantics as
+// docker.Runner, so rootless or daemonless environments can run labu
+// without a Docker daemon.
+//
+// Rootless podman's default networking is slirp4netns rather than a bridge
+// (CNI/netavark), so two quirks need handling that docker.Runner never
+// sees: a container with no explicit --network doesn't get an entry under
+// .NetworkSettings.Networks at all (InspectIP must fall back to the
+// top-level .NetworkSettings.IPAddress), and container-to-container
+// reachability needs an explicitly-created named network rather than the
+// implicit default bridge docker provides. PodmanRunner creates no network
+// implicitly: a named network must be made with CreateNetwork/
+// CreateNetworkOpts before containers are run on it.
+type PodmanRunner struct {
+	workspace   string
+	rootless    bool
+	selinuxHost bool
+	logger      *log.Logger
+
+	egressMu    sync.Mutex
+	egressRules map[string]egressAllowlistRule
+}
+
+// egressAllowlistRule records the iptables rules CreateEgressAllowlistNetwork
+// added for a network, so RemoveNetwork can tear them down again.
+type egressAllowlistRule struct {
+	subnet     string
+	allowCIDRs []string
+}
+
+// NewPodmanRunner constructs a PodmanRunner rooted at workspace. logger may
+// be nil, in which case PodmanRunner logs nothing.
+func NewPodmanRunner(workspace string, logger *log.Logger) *PodmanRunner {
+	if logger == nil {
+		logger = log.Discard()
+	}
+	return &PodmanRunner{
+		workspace:   workspace,
+		rootless:    os.Geteuid() != 0,
+		selinuxHost: selinuxEnforcing(),
+		logger:      logger,
+		egressRules: make(map[string]egressAllowlistRule),
+	}
+}
+
+// selinuxEnforcing reports whether the host is running SELinux in
+// enforcing mode; see docker.Runner's identical helper for why this reads
+// the LSM status file directly instead of shelling out to getenforce(1).
+func selinuxEnforcing() bool {
+	data, err := os.ReadFile("/sys/fs/selinux/enforce")
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(data)) == "1"
+}
+
+func (r *PodmanRunner) Build(ctxDir, dockerfile, tag string, buildArgs map[string]string) (string, error) {
+	args := []string{"build", "-t", tag}
+	if dockerfile != "" {
+		args = append(args, "-f", dockerfile)
+	}
+	for k, v := range buildArgs {
+		args = append(args, "--build-arg", fmt.Sprintf("%s=%s", k, v))
+	}
+	args = append(args, ctxDir)
+	stdout, stderr, err := r.run("podman", args...)
+	return stdout + stderr, err
+}
+
+func (r *PodmanRunner) ImageInspect(tag string) (bool, error) {
+	_, _, err := r.run("podman", "image", "inspect", tag)
+	return err == nil, nil
+}
+
+func (r *PodmanRunner) Run(cfg docker.RunConfig) (string, error) {
+	args := []string{"run", "-d"}
+	if cfg.Name != "" {
+		args = append(args, "--name", cfg.Name)
+	}
+	if cfg.Workdir != "" {
+		args = append(args, "-w", cfg.Workdir)
+	}
+	if cfg.Network != "" {
+		args = append(args, "--network", cfg.Network)
+	}
+	for _, m := range cfg.Mounts {
+		args = append(args, "-v", m.Bind(r.selinuxHost))
+	}
+	for _, vm := range cfg.VolumeMounts {
+		mountArg := fmt.Sprintf("type=volume,source=%s,target=%s", vm.Name, vm.MountPath)
+		if vm.ReadOnly {
+			mountArg += ",readonly"
+		}
+		args = append(args, "--mount", mountArg)
+	}
+	for _, d := range cfg.DNS {
+		args = append(args, "--dns", d)
+	}
+	for _, d := range cfg.DNSSearch {
+		args = append(args, "--dns-search", d)
+	}
+	for _, d := range cfg.DNSOptions {
+		args = append(args, "--dns-option", d)
+	}
+	for _, h := range cfg.ExtraHosts {
+		args = append(args, "--add-host", h)
+	}
+	for k, v := range cfg.Labels {
+		args = append(args, "--label", fmt.Sprintf("%s=%s", k, v))
+	}
+	if cfg.IP != "" {
+		args = append(args, "--ip", cfg.IP)
+	}
+	if cfg.IPv6 != "" {
+		args = append(args, "--ip6", cfg.IPv6)
+	}
+	for _, alias := range cfg.NetworkAliases {
+		args = append(args, "--network-alias", alias)
+	}
+	for k, v := range cfg.Env {
+		args = append(args, "-e", fmt.Sprintf("%s=%s", k, v))
+	}
+	if len(cfg.Entrypoint) > 0 {
+		args = append(args, "--entrypoint", strings.Join(cfg.Entrypoint, " "))
+	}
+	args = append(args, cfg.Image)
+	args = append(args, cfg.Args...)
+
+	stdout, _, err := r.run("podman", args...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(stdout), nil
+}
+
+func (r *PodmanRunner) Exec(containerID string, cmd []string) (int, string, string, error) {
+	args := append([]string{"exec", containerID}, cmd...)
+	stdout, stderr, err := r.run("podman", args...)
+	if err != nil {
+		return exitCode(err), stdout, stderr, err
+	}
+	return 0, stdout, stderr, nil
+}
+
+func (r *PodmanRunner) ExecStream(ctx context.Context, containerID string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	args := append([]string{"exec", "-i", containerID}, cmd...)
+	c := exec.CommandContext(ctx, "podman", args...)
+	c.Stdin = stdin
+	c.Stdout = stdout
+	c.Stderr = stderr
+	if err := c.Run(); err != nil {
+		return exitCode(err), err
+	}
+	return 0, nil
+}
+
+func (r *PodmanRunner) LogsStream(ctx context.Context, containerID string, opts docker.LogsOptions, w io.Writer) error {
+	args := []string{"logs"}
+	if opts.Follow {
+		args = append(args, "--follow")
+	}
+	if opts.Since != "" {
+		args = append(args, "--since", opts.Since)
+	}
+	if opts.Tail != "" {
+		args = append(args, "--tail", opts.Tail)
+	}
+	args = append(args, containerID)
+	c := exec.CommandContext(ctx, "podman", args...)
+	c.Stdout = w
+	c.Stderr = w
+	return c.Run()
+}
+
+func (r *PodmanRunner) Stop(containerID string) error {
+	_, _, err := r.run("podman", "stop", containerID)
+	return err
+}
+
+func (r *PodmanRunner) Remove(containerID string) error {
+	_, _, err := r.run("podman", "rm", "-f", containerID)
+	return err
+}
+
+func (r *PodmanRunner) Wait(containerID string) (int, error) {
+	stdout, _, err := r.run("podman", "wait", containerID)
+	if err != nil {
+		return exitCode(err), err
+	}
+	codeStr := strings.TrimSpace(stdout)
+	if codeStr == "" {
+		return 0, nil
+	}
+	var code int
+	_, scanErr := fmt.Sscanf(codeStr, "%d", &code)
+	if scanErr != nil {
+		return 0, scanErr
+	}
+	return code, nil
+}
+
+func (r *PodmanRunner) Logs(containerID string) (string, error) {
+	stdout, _, err := r.run("podman", "logs", containerID)
+	return stdout, err
+}
+
+func (r *PodmanRunner) LogsFollow(containerID string) (io.ReadCloser, error) {
+	cmd := exec.Command("podman", "logs", "--follow", containerID)
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+	if err := cmd.Start(); err != nil {
+		pr.Close()
+		pw.Close()
+		return nil, err
+	}
+	_ = pw.Close()
+	return &podmanFollowCloser{ReadCloser: pr, cmd: cmd}, nil
+}
+
+type podmanFollowCloser struct {
+	io.ReadCloser
+	cmd *exec.Cmd
+}
+
+func (f *podmanFollowCloser) Close() error {
+	err := f.ReadCloser.Close()
+	if f.cmd.Process != nil {
+		_ = f.cmd.Process.Kill()
+	}
+	_ = f.cmd.Wait()
+	return err
+}
+
+func (r *PodmanRunner) ListContainers(labelKey string) ([]string, error) {
+	stdout, _, err := r.run("podman", "ps", "-aq", "--filter", "label="+labelKey)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			out = append(out, line)
+		}
+	}
+	return out, nil
+}
+
+func (r *PodmanRunner) CreateNetwork(name string) error {
+	return r.CreateNetworkOpts(name, docker.NetworkOptions{})
+}
+
+func (r *PodmanRunner) CreateNetworkOpts(name string, opts docker.NetworkOptions) error {
+	args := []string{"network", "create"}
+	if opts.Driver != "" {
+		args = append(args, "--driver", opts.Driver)
+	}
+	if opts.Subnet != "" {
+		args = append(args, "--subnet", opts.Subnet)
+	}
+	if opts.Gateway != "" {
+		args = append(args, "--gateway", opts.Gateway)
+	}
+	args = append(args, name)
+	_, _, err := r.run("podman", args...)
+	return err
+}
+
+func (r *PodmanRunner) CreateInternalNetwork(name string) error {
+	_, _, err := r.run("podman", "network", "create", "--internal", name)
+	return err
+}
+
+// CreateEgressAllowlistNetwork creates a network the same way CreateNetwork
+// does, then scopes its egress to allowCIDRs the same way docker.Runner's
+// method of the same name does: iptables ACCEPT rules for the network's
+// subnet to itself and to each allowed CIDR, followed by a DROP of
+// everything else from that subnet. Podman has no single well-known
+// equivalent of Docker's DOCKER-USER chain across its CNI and netavark
+// network backends, so the rules are added to the generic FORWARD chain
+// instead, scoped by source subnet exactly as the Docker implementation
+// scopes DOCKER-USER.
+//
+// Rootless podman's unnamed default network uses slirp4netns, which has no
+// host-visible bridge or subnet for iptables to scope rules to; this method
+// requires a rootful daemon so the created network actually has one.
+func (r *PodmanRunner) CreateEgressAllowlistNetwork(name string, allowCIDRs []string) error {
+	if r.rootless {
+		return fmt.Errorf("podman: egress allowlist networks require a rootful podman (rootless slirp4netns has no host-visible subnet to scope iptables rules to)")
+	}
+	if err := r.CreateNetwork(name); err != nil {
+		return err
+	}
+	format := "{{(index .Subnets 0).Subnet}}"
+	stdout, stderr, err := r.run("podman", "network", "inspect", name, "--format", format)
+	if err != nil {
+		_, _, _ = r.run("podman", "network", "rm", name)
+		return fmt.Errorf("podman network inspect %s: %w: %s", name, err, strings.TrimSpace(stderr))
+	}
+	subnet := strings.TrimSpace(stdout)
+	if subnet == "" {
+		_, _, _ = r.run("podman", "network", "rm", name)
+		return fmt.Errorf("egress allowlist network %s: podman assigned no subnet", name)
+	}
+	if err := applyPodmanEgressAllowlistRules(subnet, allowCIDRs); err != nil {
+		_, _, _ = r.run("podman", "network", "rm", name)
+		return err
+	}
+	r.egressMu.Lock()
+	r.egressRules[name] = egressAllowlistRule{subnet: subnet, allowCIDRs: allowCIDRs}
+	r.egressMu.Unlock()
+	return nil
+}
+
+func applyPodmanEgressAllowlistRules(subnet string, allowCIDRs []string) error {
+	dests := append([]string{subnet}, allowCIDRs...)
+	for _, dest := range dests {
+		if err := runPodmanIptables("-A", "FORWARD", "-s", subnet, "-d", dest, "-j", "ACCEPT"); err != nil {
+			_ = removePodmanEgressAllowlistRules(subnet, allowCIDRs)
+			return err
+		}
+	}
+	if err := runPodmanIptables("-A", "FORWARD", "-s", subnet, "-j", "DROP"); err != nil {
+		_ = removePodmanEgressAllowlistRules(subnet, allowCIDRs)
+		return err
+	}
+	return nil
+}
+
+// removePodmanEgressAllowlistRules undoes applyPodmanEgressAllowlistRules.
+// Each deletion is best-effort: a rule that's already gone (or never made it
+// in, on a partial failure) is not itself an error worth surfacing.
+func removePodmanEgressAllowlistRules(subnet string, allowCIDRs []string) error {
+	dests := append([]string{subnet}, allowCIDRs...)
+	for _, dest := range dests {
+		_ = runPodmanIptables("-D", "FORWARD", "-s", subnet, "-d", dest, "-j", "ACCEPT")
+	}
+	_ = runPodmanIptables("-D", "FORWARD", "-s", subnet, "-j", "DROP")
+	return nil
+}
+
+func runPodmanIptables(args ...string) error {
+	out, err := exec.Command("iptables", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("iptables %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (r *PodmanRunner) RemoveNetwork(name string) error {
+	r.egressMu.Lock()
+	rule, hasEgressRules := r.egressRules[name]
+	delete(r.egressRules, name)
+	r.egressMu.Unlock()
+	if hasEgressRules {
+		_ = removePodmanEgressAllowlistRules(rule.subnet, rule.allowCIDRs)
+	}
+
+	_, _, err := r.run("podman", "network", "rm", name)
+	return err
+}
+
+func (r *PodmanRunner) ConnectNetwork(name, containerID string) error {
+	return r.ConnectNetworkEndpoint(name, containerID, docker.NetworkEndpoint{})
+}
+
+func (r *PodmanRunner) ConnectNetworkEndpoint(name, containerID string, ep docker.NetworkEndpoint) error {
+	args := []string{"network", "connect"}
+	if ep.IPv4 != "" {
+		args = append(args, "--ip", ep.IPv4)
+	}
+	if ep.IPv6 != "" {
+		args = append(args, "--ip6", ep.IPv6)
+	}
+	for _, alias := range ep.Aliases {
+		args = append(args, "--alias", alias)
+	}
+	args = append(args, name, containerID)
+	_, _, err := r.run("podman", args...)
+	return err
+}
+
+func (r *PodmanRunner) DisconnectNetwork(name, containerID string) error {
+	_, _, err := r.run("podman", "network", "disconnect", name, containerID)
+	return err
+}
+
+// InspectIP reports containerID's address on network. Under rootless
+// slirp4netns, a container joined to no named network has nothing under
+// .NetworkSettings.Networks, so the lookup falls back to the top-level
+// .NetworkSettings.IPAddress, which is where slirp4netns reports it.
+func (r *PodmanRunner) InspectIP(network, containerID string) (string, error) {
+	format := fmt.Sprintf("{{.NetworkSettings.Networks.%s.IPAddress}}", network)
+	stdout, _, err := r.run("podman", "inspect", "-f", format, containerID)
+	if err == nil {
+		if ip := strings.TrimSpace(stdout); ip != "" && ip != "<no value>" {
+			return ip, nil
+		}
+	}
+	if !r.rootless {
+		if err != nil {
+			return "", err
+		}
+		return "", nil
+	}
+	stdout, _, err = r.run("podman", "inspect", "-f", "{{.NetworkSettings.IPAddress}}", containerID)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(stdout), nil
+}
+
+func (r *PodmanRunner) CreateVolume(name string, opts docker.VolumeOptions) error {
+	args := []string{"volume", "create"}
+	if opts.Driver != "" {
+		args = append(args, "--driver", opts.Driver)
+	}
+	for k, v := range opts.DriverOpts {
+		args = append(args, "--opt", fmt.Sprintf("%s=%s", k, v))
+	}
+	for k, v := range opts.Labels {
+		args = append(args, "--label", fmt.Sprintf("%s=%s", k, v))
+	}
+	args = append(args, name)
+	_, _, err := r.run("podman", args...)
+	return err
+}
+
+func (r *PodmanRunner) RemoveVolume(name string) error {
+	_, _, err := r.run("podman", "volume", "rm", "-f", name)
+	return err
+}
+
+func (r *PodmanRunner) SnapshotVolume(name, destDir string) error {
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		return err
+	}
+	args := []string{
+		"run", "--rm",
+		"-v", fmt.Sprintf("%s:/volume:ro", name),
+		"-v", fmt.Sprintf("%s:/out", destDir),
+		"alpine",
+		"tar", "-czf", "/out/" + name + ".tar.gz", "-C", "/volume", ".",
+	}
+	_, _, err := r.run("podman", args...)
+	return err
+}
+
+func (r *PodmanRunner) run(name string, args ...string) (string, string, error) {
+	r.logger.Debugf("podman: %s %v", name, args)
+	cmd := exec.Command(name, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	r.logger.Tracef("podman: %s %v -> stdout=%q stderr=%q err=%v", name, args, stdout.String(), stderr.String(), err)
+	return stdout.String(), stderr.String(), err
+}
+
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if ee, ok := err.(*exec.ExitError); ok {
+		return ee.ExitCode()
+	}
+	return 1
+}
@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,14 +15,20 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/tos-network/labu/internal/backend"
 	"github.com/tos-network/labu/internal/docker"
+	"github.com/tos-network/labu/internal/errdefs"
+	"github.com/tos-network/labu/internal/log"
+	"github.com/tos-network/labu/internal/logsink"
+	"github.com/tos-network/labu/internal/operations"
 	"github.com/tos-network/labu/internal/results"
+	"golang.org/x/sync/singleflight"
 	"gopkg.in/yaml.v3"
 )
 
 type Controller struct {
 	workspace string
-	docker    *docker.Runner
+	docker    backend.Backend
 	mu        sync.Mutex
 	suiteSeq  int
 	testSeq   int
@@ -29,13 +37,21 @@ type Controller struct {
 	networks  map[string]struct{}
 	results   map[int]*results.SuiteResult
 	imageOverrides map[string]string
+	egressNetworks map[string]struct{}
+	logRing        *logsink.RingSink
+	ops            *operations.Registry
+	imageBuilds    singleflight.Group
+	volumes        map[int]map[string]struct{}
+	volumeDefs     map[string]Volume
+	logger         *log.Logger
 }
 
 type Suite struct {
-	ID          int
-	Name        string
-	Description string
-	Tests       map[int]*Test
+	ID           int
+	Name         string
+	Description  string
+	Tests        map[int]*Test
+	CreatorToken string
 }
 
 type Test struct {
@@ -45,6 +61,7 @@ type Test struct {
 	Start       string
 	End         string
 	Pass        bool
+	Status      string
 	Details     string
 	Nodes       map[string]*Node
 }
@@ -53,9 +70,11 @@ type Node struct {
 	ID             string
 	ClientName     string
 	ContainerID    string
-	IP             string
+	IPs            map[string]string
+	PrimaryNetwork string
 	LogFile        string
 	InstantiatedAt string
+	Labels         map[string]string
 }
 
 type ClientDef struct {
@@ -77,34 +96,114 @@ type TestCreate struct {
 
 type TestFinish struct {
 	Pass    bool   `json:"pass"`
+	Status  string `json:"status"`
 	Details string `json:"details"`
 }
 
 type ClientLaunchConfig struct {
-	Client      string            `json:"client"`
-	Networks    []string          `json:"networks"`
-	Environment map[string]string `json:"environment"`
+	Client         string                  `json:"client"`
+	Networks       []string                `json:"networks"`
+	Environment    map[string]string       `json:"environment"`
+	NetworkProfile *NetworkProfile         `json:"networkProfile"`
+	Labels         map[string]string       `json:"labels"`
+	IPAM           map[string]IPAMEndpoint `json:"ipam"`
+	Volumes        []VolumeMount           `json:"volumes"`
+}
+
+// Volume is a named, persistent docker volume a suite can attach to its
+// nodes for chain-data snapshots, restart tests, or warm-starting a client
+// from a fixture. Unlike the per-test files directory, it outlives any one
+// node and is only removed when the owning suite ends.
+type Volume struct {
+	Name       string            `json:"name"`
+	Driver     string            `json:"driver"`
+	DriverOpts map[string]string `json:"driverOpts"`
+	Labels     map[string]string `json:"labels"`
+}
+
+// VolumeCreate is the request body for POST .../volume.
+type VolumeCreate struct {
+	Name       string            `json:"name"`
+	Driver     string            `json:"driver"`
+	DriverOpts map[string]string `json:"driverOpts"`
+	Labels     map[string]string `json:"labels"`
+}
+
+// VolumeMount attaches a Volume created for the owning suite into a
+// launched node at MountPath.
+type VolumeMount struct {
+	Name      string `json:"name"`
+	MountPath string `json:"mountPath"`
+	ReadOnly  bool   `json:"readOnly"`
+}
+
+// IPAMEndpoint pins the IP addresses and/or DNS aliases a node gets on one
+// of its ClientLaunchConfig.Networks entries.
+type IPAMEndpoint struct {
+	IPv4    string   `json:"ipv4"`
+	IPv6    string   `json:"ipv6"`
+	Aliases []string `json:"aliases"`
+}
+
+// EgressPolicy controls what a launched node's network profile permits in
+// terms of outbound connectivity.
+type EgressPolicy string
+
+const (
+	EgressAllow     EgressPolicy = "allow"
+	EgressDeny      EgressPolicy = "deny"
+	EgressAllowlist EgressPolicy = "allowlist"
+)
+
+// NetworkProfile describes the DNS, extra-hosts and egress configuration to
+// apply to a launched client container. A nil profile keeps the existing
+// labu-net defaults.
+type NetworkProfile struct {
+	DNS        []string     `json:"dns"`
+	DNSSearch  []string     `json:"dnsSearch"`
+	DNSOptions []string     `json:"dnsOptions"`
+	ExtraHosts []string     `json:"extraHosts"`
+	Egress     EgressPolicy `json:"egress"`
+	AllowCIDRs []string     `json:"allowCIDRs"`
 }
 
 type NodeInfo struct {
-	ID string `json:"id"`
-	IP string `json:"ip"`
+	ID      string            `json:"id"`
+	IPs     map[string]string `json:"ips"`
+	Network string            `json:"network"`
+	Labels  map[string]string `json:"labels,omitempty"`
 }
 
-func New(workspace string, dockerRunner *docker.Runner) *Controller {
+// New constructs a Controller backed by be, rooted at workspace. logger may
+// be nil, in which case Controller logs nothing.
+func New(workspace string, be backend.Backend, logger *log.Logger) *Controller {
+	if logger == nil {
+		logger = log.Discard()
+	}
 	c := &Controller{
 		workspace: workspace,
-		docker:    dockerRunner,
+		docker:    be,
 		suites:    make(map[int]*Suite),
 		clients:   make(map[string]ClientDef),
 		networks:  make(map[string]struct{}),
 		results:   make(map[int]*results.SuiteResult),
 		imageOverrides: make(map[string]string),
+		egressNetworks: make(map[string]struct{}),
+		ops:            operations.NewRegistry(),
+		volumes:        make(map[int]map[string]struct{}),
+		volumeDefs:     make(map[string]Volume),
+		logger:         logger,
 	}
 	c.loadClients()
 	return c
 }
 
+// Operations returns the registry backing the /operations and /events API
+// routes.
+func (c *Controller) Operations() *operations.Registry {
+	return c.ops
+}
+
 func (c *Controller) SetImageOverrides(overrides map[string]string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -147,11 +246,13 @@ func (c *Controller) ListClients() []ClientDef {
 	return out
 }
 
-func (c *Controller) CreateSuite(req SuiteCreate) int {
+// CreateSuite starts a new suite owned by creatorToken, which authorization
+// checks later use to scope a "runner"-role token to the suites it started.
+func (c *Controller) CreateSuite(req SuiteCreate, creatorToken string) int {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.suiteSeq++
-	s := &Suite{ID: c.suiteSeq, Name: req.Name, Description: req.Description, Tests: make(map[int]*Test)}
+	s := &Suite{ID: c.suiteSeq, Name: req.Name, Description: req.Description, Tests: make(map[int]*Test), CreatorToken: creatorToken}
 	c.suites[s.ID] = s
 	c.results[s.ID] = &results.SuiteResult{
 		ID:             s.ID,
@@ -163,13 +264,37 @@ func (c *Controller) CreateSuite(req SuiteCreate) int {
 	return s.ID
 }
 
-func (c *Controller) EndSuite(id int) error {
+// SuiteCreatorToken returns the token that created suiteID, for
+// authorization checks that scope a "runner" token to its own suites.
+func (c *Controller) SuiteCreatorToken(suiteID int) (string, bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	s, ok := c.suites[suiteID]
+	if !ok {
+		return "", false
+	}
+	return s.CreatorToken, true
+}
+
+// EndSuite tears down suite id, auto-removing any volumes it created so
+// stateful test scenarios don't leak docker volumes across runs.
+func (c *Controller) EndSuite(id int) error {
+	c.mu.Lock()
 	if _, ok := c.suites[id]; !ok {
-		return errors.New("suite not found")
+		c.mu.Unlock()
+		return errdefs.NotFound(fmt.Errorf("suite %d not found", id))
 	}
 	delete(c.suites, id)
+	volNames := c.volumes[id]
+	delete(c.volumes, id)
+	for name := range volNames {
+		delete(c.volumeDefs, name)
+	}
+	c.mu.Unlock()
+
+	for name := range volNames {
+		_ = c.docker.RemoveVolume(name)
+	}
 	return nil
 }
 
@@ -178,7 +303,7 @@ func (c *Controller) CreateTest(suiteID int, req TestCreate) (int, error) {
 	defer c.mu.Unlock()
 	s, ok := c.suites[suiteID]
 	if !ok {
-		return 0, errors.New("suite not found")
+		return 0, errdefs.NotFound(fmt.Errorf("suite %d not found", suiteID))
 	}
 	c.testSeq++
 	t := &Test{
@@ -192,19 +317,30 @@ func (c *Controller) CreateTest(suiteID int, req TestCreate) (int, error) {
 	return t.ID, nil
 }
 
-func (c *Controller) EndTest(suiteID, testID int, req TestFinish) error {
+// EndTest finalizes testID and returns the TestCaseResult it recorded, so
+// callers can feed it straight to a results.ResultWriter.WriteTestCase
+// without re-deriving it from suite state.
+func (c *Controller) EndTest(suiteID, testID int, req TestFinish) (results.TestCaseResult, error) {
 	c.mu.Lock()
 	s, ok := c.suites[suiteID]
 	if !ok {
 		c.mu.Unlock()
-		return errors.New("suite not found")
+		return results.TestCaseResult{}, errdefs.NotFound(fmt.Errorf("suite %d not found", suiteID))
 	}
 	t, ok := s.Tests[testID]
 	if !ok {
 		c.mu.Unlock()
-		return errors.New("test not found")
+		return results.TestCaseResult{}, errdefs.NotFound(fmt.Errorf("test %d not found", testID))
 	}
 	t.Pass = req.Pass
+	t.Status = req.Status
+	if t.Status == "" {
+		if t.Pass {
+			t.Status = "pass"
+		} else {
+			t.Status = "fail"
+		}
+	}
 	t.Details = req.Details
 	t.End = results.NowRFC3339()
 
@@ -215,13 +351,14 @@ func (c *Controller) EndTest(suiteID, testID int, req TestFinish) error {
 		End:         t.End,
 		SummaryResult: results.SummaryResult{
 			Pass:    t.Pass,
+			Status:  t.Status,
 			Details: t.Details,
 		},
 		ClientInfo: map[string]results.ClientInfo{},
 	}
 	for id, node := range t.Nodes {
 		caseResult.ClientInfo[id] = results.ClientInfo{
-			IP:             node.IP,
+			IP:             node.IPs[node.PrimaryNetwork],
 			Name:           node.ClientName,
 			InstantiatedAt: node.InstantiatedAt,
 			LogFile:        node.LogFile,
@@ -236,7 +373,7 @@ func (c *Controller) EndTest(suiteID, testID int, req TestFinish) error {
 		_ = c.docker.Remove(node.ContainerID)
 	}
 
-	return nil
+	return caseResult, nil
 }
 
 func (c *Controller) LaunchNode(suiteID, testID int, cfg ClientLaunchConfig, files map[string]string) (NodeInfo, error) {
@@ -244,39 +381,39 @@ func (c *Controller) LaunchNode(suiteID, testID int, cfg ClientLaunchConfig, fil
 	s, ok := c.suites[suiteID]
 	if !ok {
 		c.mu.Unlock()
-		return NodeInfo{}, errors.New("suite not found")
+		return NodeInfo{}, errdefs.NotFound(fmt.Errorf("suite %d not found", suiteID))
 	}
 	t, ok := s.Tests[testID]
 	if !ok {
 		c.mu.Unlock()
-		return NodeInfo{}, errors.New("test not found")
+		return NodeInfo{}, errdefs.NotFound(fmt.Errorf("test %d not found", testID))
 	}
 	clientDef, ok := c.clients[cfg.Client]
 	if !ok {
 		c.mu.Unlock()
-		return NodeInfo{}, errors.New("unknown client")
+		return NodeInfo{}, errdefs.InvalidParameter(fmt.Errorf("unknown client %q", cfg.Client))
 	}
 	imageOverride := c.imageOverrides[cfg.Client]
 	c.mu.Unlock()
 
-	// Build image
 	imageTag := imageOverride
 	if imageTag == "" {
-		imageTag = fmt.Sprintf("labu-client-%s", cfg.Client)
-		if err := c.docker.Build(clientDef.Dir, "Dockerfile", imageTag, nil); err != nil {
-			return NodeInfo{}, err
+		tag, err := c.buildClientImage(clientDef, nil, false)
+		if err != nil {
+			return NodeInfo{}, errdefs.System(fmt.Errorf("build client image: %w", err))
 		}
+		imageTag = tag
 	}
 
 	// Prepare files directory
 	nodeDir := filepath.Join(c.workspace, "nodes", fmt.Sprintf("suite-%d", suiteID), fmt.Sprintf("test-%d", testID))
 	if err := os.MkdirAll(nodeDir, 0o755); err != nil {
-		return NodeInfo{}, err
+		return NodeInfo{}, errdefs.System(err)
 	}
 	for name, path := range files {
 		dest := filepath.Join(nodeDir, name)
 		if err := copyFile(path, dest); err != nil {
-			return NodeInfo{}, err
+			return NodeInfo{}, errdefs.System(err)
 		}
 	}
 
@@ -288,54 +425,385 @@ func (c *Controller) LaunchNode(suiteID, testID int, cfg ClientLaunchConfig, fil
 	if _, ok := env["LABU_STATE_DIR"]; !ok {
 		env["LABU_STATE_DIR"] = "/state"
 	}
-	if _, ok := env["LABU_NETWORK"]; !ok {
-		if len(cfg.Networks) > 0 {
-			env["LABU_NETWORK"] = cfg.Networks[0]
-		} else {
-			env["LABU_NETWORK"] = "devnet"
+	// The primary network is whatever cfg.Networks[0] names; with no
+	// explicit networks we fall back to the suite's egress-policy default
+	// (labu-net, or a per-suite isolated network for deny/allowlist
+	// profiles).
+	var primaryNetwork string
+	if len(cfg.Networks) > 0 {
+		primaryNetwork = cfg.Networks[0]
+	} else {
+		pn, err := c.networkForProfile(suiteID, cfg.NetworkProfile)
+		if err != nil {
+			return NodeInfo{}, errdefs.Unavailable(err)
 		}
+		primaryNetwork = pn
 	}
 
-	mounts := []string{
-		fmt.Sprintf("%s:/labu-files:ro", nodeDir),
+	if _, ok := env["LABU_NETWORK"]; !ok {
+		env["LABU_NETWORK"] = primaryNetwork
 	}
 
-	containerID, err := c.docker.Run(docker.RunConfig{
-		Image:   imageTag,
-		Env:     env,
-		Mounts:  mounts,
-		Network: "labu-net",
-	})
+	// private: nodeDir belongs to exactly this one node, so it's relabeled
+	// for its exclusive use rather than shared across containers.
+	mounts := []docker.Mount{
+		{Source: nodeDir, Target: "/labu-files", ReadOnly: true, SELinux: docker.SELinuxPrivate},
+	}
+
+	labels := map[string]string{
+		"labu.suite":  strconv.Itoa(suiteID),
+		"labu.test":   strconv.Itoa(testID),
+		"labu.client": cfg.Client,
+	}
+	for k, v := range cfg.Labels {
+		labels[k] = v
+	}
+
+	var volumeMounts []docker.VolumeMount
+	for _, vm := range cfg.Volumes {
+		volumeMounts = append(volumeMounts, docker.VolumeMount{Name: vm.Name, MountPath: vm.MountPath, ReadOnly: vm.ReadOnly})
+	}
+
+	runCfg := docker.RunConfig{
+		Image:        imageTag,
+		Env:          env,
+		Mounts:       mounts,
+		Network:      primaryNetwork,
+		Labels:       labels,
+		VolumeMounts: volumeMounts,
+	}
+	if ep, ok := cfg.IPAM[primaryNetwork]; ok {
+		runCfg.IP = ep.IPv4
+		runCfg.IPv6 = ep.IPv6
+		runCfg.NetworkAliases = ep.Aliases
+	}
+	if cfg.NetworkProfile != nil {
+		runCfg.DNS = cfg.NetworkProfile.DNS
+		runCfg.DNSSearch = cfg.NetworkProfile.DNSSearch
+		runCfg.DNSOptions = cfg.NetworkProfile.DNSOptions
+		runCfg.ExtraHosts = cfg.NetworkProfile.ExtraHosts
+	}
+
+	containerID, err := c.docker.Run(runCfg)
 	if err != nil {
-		return NodeInfo{}, err
+		return NodeInfo{}, errdefs.Unavailable(err)
+	}
+	go c.streamNodeLogs(cfg.Client, containerID)
+
+	ips := map[string]string{}
+	if ip, err := c.docker.InspectIP(primaryNetwork, containerID); err == nil {
+		ips[primaryNetwork] = ip
+	}
+
+	// Join any additional requested networks; the container was only
+	// attached to the primary one at creation time.
+	if len(cfg.Networks) > 1 {
+		for _, netName := range cfg.Networks[1:] {
+			var ep docker.NetworkEndpoint
+			if e, ok := cfg.IPAM[netName]; ok {
+				ep = docker.NetworkEndpoint{IPv4: e.IPv4, IPv6: e.IPv6, Aliases: e.Aliases}
+			}
+			if err := c.docker.ConnectNetworkEndpoint(netName, containerID, ep); err != nil {
+				return NodeInfo{}, errdefs.Unavailable(fmt.Errorf("connect network %s: %w", netName, err))
+			}
+			if ip, err := c.docker.InspectIP(netName, containerID); err == nil {
+				ips[netName] = ip
+			}
+		}
 	}
 
-	ip, _ := c.docker.InspectIP("labu-net", containerID)
 	node := &Node{
 		ID:             containerID,
 		ClientName:     cfg.Client,
 		ContainerID:    containerID,
-		IP:             ip,
+		IPs:            ips,
+		PrimaryNetwork: primaryNetwork,
 		InstantiatedAt: results.NowRFC3339(),
 		LogFile:        filepath.Join("clients", cfg.Client, "client-"+containerID+".log"),
+		Labels:         labels,
 	}
 
 	c.mu.Lock()
 	t.Nodes[node.ID] = node
 	c.mu.Unlock()
 
-	return NodeInfo{ID: containerID, IP: ip}, nil
+	return NodeInfo{ID: containerID, IPs: ips, Network: primaryNetwork, Labels: labels}, nil
+}
+
+// streamNodeLogs follows containerID's combined output for as long as the
+// container runs and logs each line at Debug with a client=<name>-<short
+// ID> field attached, so parallel client containers interleave readably in
+// labu's own log output instead of only being visible through `docker
+// logs` per-container.
+func (c *Controller) streamNodeLogs(clientName, containerID string) {
+	reader, err := c.docker.LogsFollow(containerID)
+	if err != nil {
+		return
+	}
+	defer reader.Close()
+	short := containerID
+	if len(short) > 12 {
+		short = short[:12]
+	}
+	tagged := c.logger.With("client", fmt.Sprintf("%s-%s", clientName, short))
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		tagged.Debugf("%s", scanner.Text())
+	}
+}
+
+// ListNodes returns the nodes launched for a test, optionally filtered to
+// those carrying a "key=value" label. An empty labelFilter returns every
+// node.
+func (c *Controller) ListNodes(suiteID, testID int, labelFilter string) ([]NodeInfo, error) {
+	key, value, filtering := "", "", false
+	if labelFilter != "" {
+		parts := strings.SplitN(labelFilter, "=", 2)
+		if len(parts) != 2 {
+			return nil, errdefs.InvalidParameter(fmt.Errorf("label filter %q must be key=value", labelFilter))
+		}
+		key, value, filtering = parts[0], parts[1], true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.suites[suiteID]
+	if !ok {
+		return nil, errdefs.NotFound(fmt.Errorf("suite %d not found", suiteID))
+	}
+	t, ok := s.Tests[testID]
+	if !ok {
+		return nil, errdefs.NotFound(fmt.Errorf("test %d not found", testID))
+	}
+
+	out := make([]NodeInfo, 0, len(t.Nodes))
+	for _, node := range t.Nodes {
+		if filtering && node.Labels[key] != value {
+			continue
+		}
+		out = append(out, NodeInfo{ID: node.ID, IPs: node.IPs, Network: node.PrimaryNetwork, Labels: node.Labels})
+	}
+	return out, nil
+}
+
+// GC removes containers tagged with a labu.suite label that this controller
+// is no longer tracking, i.e. ones orphaned by a prior crashed run. It
+// returns the IDs it removed.
+func (c *Controller) GC() ([]string, error) {
+	ids, err := c.docker.ListContainers("labu.suite")
+	if err != nil {
+		return nil, errdefs.Unavailable(err)
+	}
+
+	c.mu.Lock()
+	tracked := make(map[string]struct{})
+	for _, suite := range c.suites {
+		for _, test := range suite.Tests {
+			for id := range test.Nodes {
+				tracked[id] = struct{}{}
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	var removed []string
+	for _, id := range ids {
+		if _, ok := tracked[id]; ok {
+			continue
+		}
+		if err := c.docker.Remove(id); err != nil {
+			continue
+		}
+		removed = append(removed, id)
+	}
+	return removed, nil
 }
 
-func (c *Controller) CreateNetwork(name string) error {
+// Shutdown removes every container and egress network the controller has
+// launched, in parallel, so an interrupted run doesn't leak them. It runs
+// until ctx is done rather than until every removal finishes, so callers
+// bounding this with a timeout get control back even if a docker call hangs
+// (the goroutines themselves are left to finish or fail in the background).
+func (c *Controller) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	var containerIDs []string
+	for _, suite := range c.suites {
+		for _, test := range suite.Tests {
+			for id := range test.Nodes {
+				containerIDs = append(containerIDs, id)
+			}
+		}
+	}
+	var networkNames []string
+	for name := range c.egressNetworks {
+		networkNames = append(networkNames, name)
+	}
+	c.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, id := range containerIDs {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			_ = c.docker.Remove(id)
+		}(id)
+	}
+	for _, name := range networkNames {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			_ = c.docker.RemoveNetwork(name)
+		}(name)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// networkForProfile returns the docker network a node should attach to for
+// the given suite and profile. EgressDeny (or EgressAllowlist with no CIDRs
+// given, which is indistinguishable from deny) is isolated onto a per-suite
+// internal-only network so it cannot reach anything outside the other
+// containers attached to it. EgressAllowlist with CIDRs given gets the same
+// per-suite network plus host-level rules scoping its egress to those CIDRs.
+// "allow" (or a nil profile) uses the shared labu-net bridge.
+func (c *Controller) networkForProfile(suiteID int, profile *NetworkProfile) (string, error) {
+	if profile == nil || profile.Egress == "" || profile.Egress == EgressAllow {
+		return "labu-net", nil
+	}
+	name := fmt.Sprintf("labu-egress-%d", suiteID)
+	c.mu.Lock()
+	_, exists := c.egressNetworks[name]
+	c.mu.Unlock()
+	if exists {
+		return name, nil
+	}
+	if profile.Egress == EgressAllowlist && len(profile.AllowCIDRs) > 0 {
+		if err := c.docker.CreateEgressAllowlistNetwork(name, profile.AllowCIDRs); err != nil {
+			return "", fmt.Errorf("create egress allowlist network: %w", err)
+		}
+	} else if err := c.docker.CreateInternalNetwork(name); err != nil {
+		return "", fmt.Errorf("create egress network: %w", err)
+	}
+	c.mu.Lock()
+	c.egressNetworks[name] = struct{}{}
+	c.mu.Unlock()
+	return name, nil
+}
+
+// buildClientImage returns the content-addressed image tag for clientDef,
+// building it only if that tag isn't already present (or force is set).
+// Concurrent callers racing to build the same tag are coalesced onto a
+// single docker build via imageBuilds.
+func (c *Controller) buildClientImage(clientDef ClientDef, buildArgs map[string]string, force bool) (string, error) {
+	hash, err := imageCacheKey(clientDef.Dir, buildArgs)
+	if err != nil {
+		return "", err
+	}
+	tag := fmt.Sprintf("labu-client-%s:%s", clientDef.Name, hash[:12])
+
+	if !force {
+		if exists, _ := c.docker.ImageInspect(tag); exists {
+			return tag, nil
+		}
+	}
+
+	_, err, _ = c.imageBuilds.Do(tag, func() (interface{}, error) {
+		op := c.ops.Start(operations.ClassTask, map[string]interface{}{
+			"action": "build", "client": clientDef.Name, "tag": tag,
+		}, nil)
+		buildLog, buildErr := c.docker.Build(clientDef.Dir, "Dockerfile", tag, buildArgs)
+		c.ops.Finish(op, buildErr, map[string]interface{}{"tag": tag, "log": buildLog})
+		return nil, buildErr
+	})
+	if err != nil {
+		return "", err
+	}
+	return tag, nil
+}
+
+// ClientImage reports the content-addressed tag that name's current source
+// tree hashes to, and whether it's already built.
+func (c *Controller) ClientImage(name string) (map[string]interface{}, error) {
+	c.mu.Lock()
+	clientDef, ok := c.clients[name]
+	override := c.imageOverrides[name]
+	c.mu.Unlock()
+	if !ok {
+		return nil, errdefs.NotFound(fmt.Errorf("unknown client %q", name))
+	}
+	if override != "" {
+		built, _ := c.docker.ImageInspect(override)
+		return map[string]interface{}{"tag": override, "built": built}, nil
+	}
+
+	hash, err := imageCacheKey(clientDef.Dir, nil)
+	if err != nil {
+		return nil, errdefs.System(err)
+	}
+	tag := fmt.Sprintf("labu-client-%s:%s", name, hash[:12])
+	built, _ := c.docker.ImageInspect(tag)
+	return map[string]interface{}{"tag": tag, "built": built}, nil
+}
+
+// RebuildClientImage forces a rebuild of name's image, bypassing the cache
+// check, and returns the operation tracking the build.
+func (c *Controller) RebuildClientImage(name string) (*operations.Operation, error) {
+	c.mu.Lock()
+	clientDef, ok := c.clients[name]
+	c.mu.Unlock()
+	if !ok {
+		return nil, errdefs.NotFound(fmt.Errorf("unknown client %q", name))
+	}
+
+	op := c.ops.Start(operations.ClassTask, map[string]interface{}{
+		"action": "rebuild", "client": name,
+	}, nil)
+	go func() {
+		tag, err := c.buildClientImage(clientDef, nil, true)
+		c.ops.Finish(op, err, map[string]interface{}{"tag": tag})
+	}()
+	return op, nil
+}
+
+// CreateNetworkAsync starts network creation in the background and returns
+// an Operation the caller can poll or wait on, since docker network create
+// can stall if the daemon is under load and has no reason to block the
+// HTTP request that triggered it.
+func (c *Controller) CreateNetworkAsync(name string, opts docker.NetworkOptions) *operations.Operation {
+	op := c.ops.Start(operations.ClassTask, map[string]interface{}{
+		"action": "create-network", "name": name,
+	}, nil)
+
 	c.mu.Lock()
 	if _, ok := c.networks[name]; ok {
 		c.mu.Unlock()
-		return nil
+		c.ops.Finish(op, nil, map[string]interface{}{"name": name})
+		return op
 	}
 	c.networks[name] = struct{}{}
 	c.mu.Unlock()
-	return c.docker.CreateNetwork(name)
+
+	go func() {
+		err := c.docker.CreateNetworkOpts(name, opts)
+		if err != nil {
+			c.mu.Lock()
+			delete(c.networks, name)
+			c.mu.Unlock()
+		}
+		c.ops.Finish(op, err, map[string]interface{}{"name": name})
+	}()
+	return op
 }
 
 func (c *Controller) RemoveNetwork(name string) error {
@@ -357,7 +825,110 @@ func (c *Controller) NetworkIP(name, containerID string) (string, error) {
 	return c.docker.InspectIP(name, containerID)
 }
 
-func (c *Controller) SaveResults(writer *results.Writer) error {
+// CreateVolume creates a persistent docker volume scoped to suiteID, tracked
+// so EndSuite can remove it automatically.
+func (c *Controller) CreateVolume(suiteID int, req VolumeCreate) (Volume, error) {
+	c.mu.Lock()
+	if _, ok := c.suites[suiteID]; !ok {
+		c.mu.Unlock()
+		return Volume{}, errdefs.NotFound(fmt.Errorf("suite %d not found", suiteID))
+	}
+	c.mu.Unlock()
+
+	if req.Name == "" {
+		return Volume{}, errdefs.InvalidParameter(fmt.Errorf("volume name is required"))
+	}
+
+	opts := docker.VolumeOptions{Driver: req.Driver, DriverOpts: req.DriverOpts, Labels: req.Labels}
+	if err := c.docker.CreateVolume(req.Name, opts); err != nil {
+		return Volume{}, errdefs.Unavailable(err)
+	}
+
+	vol := Volume{Name: req.Name, Driver: req.Driver, DriverOpts: req.DriverOpts, Labels: req.Labels}
+
+	c.mu.Lock()
+	if c.volumes[suiteID] == nil {
+		c.volumes[suiteID] = make(map[string]struct{})
+	}
+	c.volumes[suiteID][req.Name] = struct{}{}
+	c.volumeDefs[req.Name] = vol
+	c.mu.Unlock()
+
+	return vol, nil
+}
+
+// RemoveVolume removes a volume created for suiteID ahead of EndSuite's
+// automatic cleanup, e.g. to free space mid-run.
+func (c *Controller) RemoveVolume(suiteID int, name string) error {
+	c.mu.Lock()
+	if _, ok := c.suites[suiteID]; !ok {
+		c.mu.Unlock()
+		return errdefs.NotFound(fmt.Errorf("suite %d not found", suiteID))
+	}
+	if _, ok := c.volumes[suiteID][name]; !ok {
+		c.mu.Unlock()
+		return errdefs.NotFound(fmt.Errorf("volume %q not found", name))
+	}
+	delete(c.volumes[suiteID], name)
+	delete(c.volumeDefs, name)
+	c.mu.Unlock()
+
+	if err := c.docker.RemoveVolume(name); err != nil {
+		return errdefs.Unavailable(err)
+	}
+	return nil
+}
+
+// ListVolumes returns the volumes created for suiteID.
+func (c *Controller) ListVolumes(suiteID int) ([]Volume, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.suites[suiteID]; !ok {
+		return nil, errdefs.NotFound(fmt.Errorf("suite %d not found", suiteID))
+	}
+	names := c.volumes[suiteID]
+	out := make([]Volume, 0, len(names))
+	for name := range names {
+		out = append(out, c.volumeDefs[name])
+	}
+	return out, nil
+}
+
+// SnapshotVolume tars name's current contents into the results directory via
+// a helper container, for post-mortem inspection of stateful test runs
+// without needing a live shell into the node that used it.
+func (c *Controller) SnapshotVolume(suiteID int, name string) (string, error) {
+	c.mu.Lock()
+	_, suiteOK := c.suites[suiteID]
+	_, volOK := c.volumes[suiteID][name]
+	c.mu.Unlock()
+	if !suiteOK {
+		return "", errdefs.NotFound(fmt.Errorf("suite %d not found", suiteID))
+	}
+	if !volOK {
+		return "", errdefs.NotFound(fmt.Errorf("volume %q not found", name))
+	}
+
+	destDir := filepath.Join(c.workspace, "results", "volumes", fmt.Sprintf("suite-%d", suiteID))
+	if err := c.docker.SnapshotVolume(name, destDir); err != nil {
+		return "", errdefs.Unavailable(err)
+	}
+	return filepath.Join(destDir, name+".tar.gz"), nil
+}
+
+// CompletedTestCount returns the number of test cases recorded across all
+// suites so far, for progress reporting during a run.
+func (c *Controller) CompletedTestCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, res := range c.results {
+		n += len(res.TestCases)
+	}
+	return n
+}
+
+func (c *Controller) SaveResults(writer results.ResultWriter) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	for _, res := range c.results {
@@ -372,6 +943,19 @@ func (c *Controller) DockerExec(containerID string, cmd []string) (int, string,
 	return c.docker.Exec(containerID, cmd)
 }
 
+// ExecStream runs cmd in containerID, streaming stdin/stdout/stderr live
+// instead of buffering them. Cancelling ctx (e.g. the caller's websocket
+// closing) kills the exec process.
+func (c *Controller) ExecStream(ctx context.Context, containerID string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	return c.docker.ExecStream(ctx, containerID, cmd, stdin, stdout, stderr)
+}
+
+// LogsStream streams containerID's logs to w, optionally following new
+// output as it's written.
+func (c *Controller) LogsStream(ctx context.Context, containerID string, opts docker.LogsOptions, w io.Writer) error {
+	return c.docker.LogsStream(ctx, containerID, opts, w)
+}
+
 func (c *Controller) SetSimLog(logFile string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -380,6 +964,27 @@ func (c *Controller) SetSimLog(logFile string) {
 	}
 }
 
+// SetLogRing installs the ring buffer that TailLogs reads from. It is wired
+// up once by sim.Run before the simulator container starts.
+func (c *Controller) SetLogRing(ring *logsink.RingSink) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logRing = ring
+}
+
+// TailLogs returns up to limit recent log lines at or above minSeverity,
+// optionally filtered to a single container. It returns nil if no log ring
+// has been configured.
+func (c *Controller) TailLogs(container string, minSeverity logsink.Severity, limit int) []logsink.Line {
+	c.mu.Lock()
+	ring := c.logRing
+	c.mu.Unlock()
+	if ring == nil {
+		return nil
+	}
+	return ring.Tail(container, minSeverity, limit)
+}
+
 func (c *Controller) SetClientVersions(names []string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// imageCacheKey hashes a client directory's contents (including its
+// Dockerfile, which lives inside dir) together with buildArgs, mixing each
+// file's mode so that permission-only changes also invalidate the cache.
+// filepath.Walk visits entries in lexical order, so the result is stable
+// across runs without an explicit sort of the file list.
+func imageCacheKey(dir string, buildArgs map[string]string) (string, error) {
+	h := sha256.New()
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\t%o\n", rel, info.Mode())
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	keys := make([]string, 0, len(buildArgs))
+	for k := range buildArgs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%s\n", k, buildArgs[k])
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeClientDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestImageCacheKeyStableAcrossRuns(t *testing.T) {
+	dir := writeClientDir(t, map[string]string{"Dockerfile": "FROM scratch\n"})
+
+	k1, err := imageCacheKey(dir, nil)
+	if err != nil {
+		t.Fatalf("imageCacheKey: %v", err)
+	}
+	k2, err := imageCacheKey(dir, nil)
+	if err != nil {
+		t.Fatalf("imageCacheKey: %v", err)
+	}
+	if k1 != k2 {
+		t.Errorf("hash not stable across runs: %s != %s", k1, k2)
+	}
+}
+
+func TestImageCacheKeyChangesWithContent(t *testing.T) {
+	dir := writeClientDir(t, map[string]string{"Dockerfile": "FROM scratch\n"})
+	before, err := imageCacheKey(dir, nil)
+	if err != nil {
+		t.Fatalf("imageCacheKey: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM scratch\nRUN true\n"), 0o644); err != nil {
+		t.Fatalf("rewrite Dockerfile: %v", err)
+	}
+	after, err := imageCacheKey(dir, nil)
+	if err != nil {
+		t.Fatalf("imageCacheKey: %v", err)
+	}
+	if before == after {
+		t.Error("hash did not change after file content changed")
+	}
+}
+
+func TestImageCacheKeyChangesWithMode(t *testing.T) {
+	dir := writeClientDir(t, map[string]string{"entrypoint.sh": "#!/bin/sh\necho hi\n"})
+	before, err := imageCacheKey(dir, nil)
+	if err != nil {
+		t.Fatalf("imageCacheKey: %v", err)
+	}
+
+	if err := os.Chmod(filepath.Join(dir, "entrypoint.sh"), 0o755); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+	after, err := imageCacheKey(dir, nil)
+	if err != nil {
+		t.Fatalf("imageCacheKey: %v", err)
+	}
+	if before == after {
+		t.Error("hash did not change after file mode changed")
+	}
+}
+
+func TestImageCacheKeyChangesWithBuildArgs(t *testing.T) {
+	dir := writeClientDir(t, map[string]string{"Dockerfile": "FROM scratch\n"})
+
+	k1, err := imageCacheKey(dir, map[string]string{"VERSION": "1"})
+	if err != nil {
+		t.Fatalf("imageCacheKey: %v", err)
+	}
+	k2, err := imageCacheKey(dir, map[string]string{"VERSION": "2"})
+	if err != nil {
+		t.Fatalf("imageCacheKey: %v", err)
+	}
+	if k1 == k2 {
+		t.Error("hash did not change when buildArgs changed")
+	}
+}
+
+func TestImageCacheKeyBuildArgOrderIndependent(t *testing.T) {
+	dir := writeClientDir(t, map[string]string{"Dockerfile": "FROM scratch\n"})
+
+	k1, err := imageCacheKey(dir, map[string]string{"A": "1", "B": "2"})
+	if err != nil {
+		t.Fatalf("imageCacheKey: %v", err)
+	}
+	k2, err := imageCacheKey(dir, map[string]string{"B": "2", "A": "1"})
+	if err != nil {
+		t.Fatalf("imageCacheKey: %v", err)
+	}
+	if k1 != k2 {
+		t.Errorf("hash depends on buildArgs map iteration order: %s != %s", k1, k2)
+	}
+}
@@ -1,159 +1,1013 @@
 package docker
 
 import (
-	"bytes"
+	"bufio"
+	"compress/gzip"
+	"context"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
 	"strings"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	dockererrdefs "github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/stdcopy"
+
+	"github.com/tos-network/labu/internal/errdefs"
+	"github.com/tos-network/labu/internal/log"
 )
 
+// Runner talks to the Docker Engine API over the local socket (or
+// DOCKER_HOST) instead of shelling out to the docker binary, so calls carry
+// real errors and respect ctx cancellation instead of blocking in an
+// untracked child process. cli is created lazily: most Runner values are
+// constructed well before anything needs a daemon connection.
 type Runner struct {
-	workspace string
+	workspace   string
+	cli         *client.Client
+	selinuxHost bool
+	logger      *log.Logger
+
+	egressMu    sync.Mutex
+	egressRules map[string]egressAllowlistRule
+}
+
+// egressAllowlistRule records what CreateEgressAllowlistNetwork applied for
+// one network, so RemoveNetwork can undo exactly those iptables rules.
+type egressAllowlistRule struct {
+	subnet     string
+	allowCIDRs []string
 }
 
 type RunConfig struct {
 	Image      string
 	Name       string
 	Env        map[string]string
-	Mounts     []string
+	Mounts     []Mount
 	Network    string
 	Workdir    string
 	Entrypoint []string
 	Args       []string
+
+	// DNS, DNSSearch and DNSOptions map to docker run's --dns/--dns-search/
+	// --dns-option flags. ExtraHosts entries are "host:ip" pairs applied via
+	// --add-host.
+	DNS        []string
+	DNSSearch  []string
+	DNSOptions []string
+	ExtraHosts []string
+
+	// Labels are applied via --label and are how GC and label-filtered
+	// node listing find containers after the controller that launched
+	// them is gone.
+	Labels map[string]string
+
+	// IP, IPv6 and NetworkAliases configure the endpoint on Network at
+	// container creation (--ip/--ip6/--network-alias). Additional
+	// networks joined after creation carry their own NetworkEndpoint via
+	// ConnectNetworkEndpoint.
+	IP             string
+	IPv6           string
+	NetworkAliases []string
+
+	// VolumeMounts attaches named, persistent docker volumes (created via
+	// CreateVolume) as opposed to Mounts' host-path bind mounts.
+	VolumeMounts []VolumeMount
+}
+
+// NetworkOptions configures a docker network at creation time.
+type NetworkOptions struct {
+	Subnet  string
+	Gateway string
+	Driver  string
+}
+
+// VolumeOptions configures a docker volume at creation time.
+type VolumeOptions struct {
+	Driver     string
+	DriverOpts map[string]string
+	Labels     map[string]string
+}
+
+// VolumeMount attaches a volume created with CreateVolume into a container
+// at MountPath, optionally read-only.
+type VolumeMount struct {
+	Name      string
+	MountPath string
+	ReadOnly  bool
+}
+
+// SELinuxLabel is the relabeling suffix docker applies to a bind mount on an
+// SELinux-enforcing host, so a confined container's type can actually read
+// (and, if not ReadOnly, write) the host path. "" leaves the mount
+// unlabeled, which is correct on non-SELinux hosts and is the only option
+// that's meaningful there.
+type SELinuxLabel string
+
+const (
+	// SELinuxShared renders as ":z": the path is shared across multiple
+	// containers' confinement, each getting read/write to the same
+	// relabeled content. Use for paths several containers read at once,
+	// like a vectors directory.
+	SELinuxShared SELinuxLabel = "shared"
+	// SELinuxPrivate renders as ":Z": the path is relabeled for exclusive
+	// use by one container, and other confined containers lose access to
+	// it. Use for a single container's own workspace subdirectory.
+	SELinuxPrivate SELinuxLabel = "private"
+)
+
+// Mount is a bind mount from Source on the host to Target in the
+// container. SELinux is ignored unless the host is SELinux-enforcing (see
+// NewRunner), so callers can set it unconditionally for paths with the
+// right sharing semantics without checking the host themselves.
+type Mount struct {
+	Source      string
+	Target      string
+	ReadOnly    bool
+	Propagation string
+	SELinux     SELinuxLabel
+}
+
+// Bind renders m as a "docker run -v" style bind-mount string, applying the
+// SELinux relabeling suffix only when selinuxHost is true. Exported so
+// other Backend implementations (e.g. PodmanRunner, which understands the
+// same :z/:Z suffixes) can reuse the same rendering instead of duplicating
+// it.
+func (m Mount) Bind(selinuxHost bool) string {
+	bind := fmt.Sprintf("%s:%s", m.Source, m.Target)
+	var opts []string
+	if m.ReadOnly {
+		opts = append(opts, "ro")
+	}
+	if m.Propagation != "" {
+		opts = append(opts, m.Propagation)
+	}
+	if selinuxHost {
+		switch m.SELinux {
+		case SELinuxShared:
+			opts = append(opts, "z")
+		case SELinuxPrivate:
+			opts = append(opts, "Z")
+		}
+	}
+	if len(opts) > 0 {
+		bind += ":" + strings.Join(opts, ",")
+	}
+	return bind
+}
+
+// NetworkEndpoint configures the IP addresses and aliases a container gets
+// when joining a network, whether at creation (via RunConfig) or afterward
+// (via ConnectNetworkEndpoint).
+type NetworkEndpoint struct {
+	IPv4    string
+	IPv6    string
+	Aliases []string
+}
+
+// NewRunner constructs a Runner rooted at workspace. logger may be nil, in
+// which case Runner logs nothing.
+func NewRunner(workspace string, logger *log.Logger) *Runner {
+	if logger == nil {
+		logger = log.Discard()
+	}
+	return &Runner{workspace: workspace, selinuxHost: selinuxEnforcing(), logger: logger, egressRules: make(map[string]egressAllowlistRule)}
+}
+
+// selinuxEnforcing reports whether the host is running SELinux in
+// enforcing mode, by reading the kernel's LSM status file directly rather
+// than shelling out to getenforce(1), which may not be installed even on
+// an enforcing host's minimal container image.
+func selinuxEnforcing() bool {
+	data, err := os.ReadFile("/sys/fs/selinux/enforce")
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(data)) == "1"
 }
 
-func NewRunner(workspace string) *Runner {
-	return &Runner{workspace: workspace}
+// client lazily dials the daemon named by DOCKER_HOST (or the platform
+// default socket), negotiating the API version so Runner keeps working
+// against daemons older or newer than the client library.
+func (r *Runner) client() (*client.Client, error) {
+	if r.cli != nil {
+		return r.cli, nil
+	}
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, errdefs.Unavailable(fmt.Errorf("connect to docker daemon: %w", err))
+	}
+	r.cli = cli
+	return cli, nil
 }
 
-func (r *Runner) Build(ctxDir, dockerfile, tag string, buildArgs map[string]string) error {
-	args := []string{"build", "-t", tag}
-	if dockerfile != "" {
-		args = append(args, "-f", dockerfile)
+// wrapErr classifies an Engine API error into the errdefs category callers
+// switch on, falling back to Unavailable since most client-level failures
+// here mean the daemon couldn't be reached at all.
+func wrapErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch {
+	case client.IsErrNotFound(err):
+		return errdefs.NotFound(err)
+	case dockererrdefs.IsConflict(err):
+		return errdefs.Conflict(err)
+	default:
+		return errdefs.Unavailable(err)
 	}
+}
+
+// Build returns the combined build log so callers can retain it (e.g. in an
+// operation's metadata) for later inspection.
+func (r *Runner) Build(ctxDir, dockerfile, tag string, buildArgs map[string]string) (string, error) {
+	return r.BuildContext(context.Background(), ctxDir, dockerfile, tag, buildArgs)
+}
+
+// BuildContext is Build with an explicit ctx, so a cancelled simulation
+// aborts an in-flight image build instead of waiting it out.
+func (r *Runner) BuildContext(ctx context.Context, ctxDir, dockerfile, tag string, buildArgs map[string]string) (string, error) {
+	r.logger.Debugf("docker: build ctxDir=%s dockerfile=%s tag=%s", ctxDir, dockerfile, tag)
+	cli, err := r.client()
+	if err != nil {
+		return "", err
+	}
+	tarball, err := archive.TarWithOptions(ctxDir, &archive.TarOptions{})
+	if err != nil {
+		return "", errdefs.System(fmt.Errorf("tar build context: %w", err))
+	}
+	defer tarball.Close()
+
+	args := map[string]*string{}
 	for k, v := range buildArgs {
-		args = append(args, "--build-arg", fmt.Sprintf("%s=%s", k, v))
+		v := v
+		args[k] = &v
 	}
-	args = append(args, ctxDir)
-	_, _, err := r.run("docker", args...)
-	return err
+	opts := types.ImageBuildOptions{
+		Tags:       []string{tag},
+		Dockerfile: relativeDockerfile(ctxDir, dockerfile),
+		BuildArgs:  args,
+		Remove:     true,
+	}
+	resp, err := cli.ImageBuild(ctx, tarball, opts)
+	if err != nil {
+		return "", wrapErr(err)
+	}
+	defer resp.Body.Close()
+
+	var buildLog strings.Builder
+	if _, err := io.Copy(&buildLog, resp.Body); err != nil {
+		return buildLog.String(), errdefs.System(err)
+	}
+	r.logger.Tracef("docker: build %s output: %s", tag, buildLog.String())
+	return buildLog.String(), nil
+}
+
+// relativeDockerfile returns dockerfile relative to ctxDir, since the
+// Engine API resolves Dockerfile against the tar'd build context rather
+// than the host filesystem.
+func relativeDockerfile(ctxDir, dockerfile string) string {
+	if dockerfile == "" {
+		return "Dockerfile"
+	}
+	if rel := strings.TrimPrefix(dockerfile, ctxDir); rel != dockerfile {
+		return strings.TrimPrefix(rel, "/")
+	}
+	return dockerfile
+}
+
+// ImageInspect reports whether tag already exists locally, so callers can
+// skip a redundant build.
+func (r *Runner) ImageInspect(tag string) (bool, error) {
+	cli, err := r.client()
+	if err != nil {
+		return false, err
+	}
+	_, _, err = cli.ImageInspectWithRaw(context.Background(), tag)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return false, nil
+		}
+		return false, wrapErr(err)
+	}
+	return true, nil
 }
 
 func (r *Runner) Run(cfg RunConfig) (string, error) {
-	args := []string{"run", "-d"}
-	if cfg.Name != "" {
-		args = append(args, "--name", cfg.Name)
+	return r.RunContext(context.Background(), cfg)
+}
+
+// RunContext is Run with an explicit ctx, so a cancelled simulation can
+// abort a stuck container create/start instead of blocking indefinitely.
+func (r *Runner) RunContext(ctx context.Context, cfg RunConfig) (string, error) {
+	r.logger.Debugf("docker: run image=%s name=%s network=%s mounts=%d", cfg.Image, cfg.Name, cfg.Network, len(cfg.Mounts))
+	cli, err := r.client()
+	if err != nil {
+		return "", err
 	}
-	if cfg.Workdir != "" {
-		args = append(args, "-w", cfg.Workdir)
+
+	containerCfg := &container.Config{
+		Image:      cfg.Image,
+		Env:        envSlice(cfg.Env),
+		Labels:     cfg.Labels,
+		WorkingDir: cfg.Workdir,
 	}
-	if cfg.Network != "" {
-		args = append(args, "--network", cfg.Network)
+	if len(cfg.Entrypoint) > 0 {
+		containerCfg.Entrypoint = cfg.Entrypoint
 	}
+	containerCfg.Cmd = cfg.Args
+
+	binds := make([]string, 0, len(cfg.Mounts))
 	for _, m := range cfg.Mounts {
-		args = append(args, "-v", m)
+		binds = append(binds, m.Bind(r.selinuxHost))
 	}
-	for k, v := range cfg.Env {
-		args = append(args, "-e", fmt.Sprintf("%s=%s", k, v))
+	hostCfg := &container.HostConfig{
+		Binds:      binds,
+		DNS:        cfg.DNS,
+		DNSSearch:  cfg.DNSSearch,
+		DNSOptions: cfg.DNSOptions,
+		ExtraHosts: cfg.ExtraHosts,
 	}
-	if len(cfg.Entrypoint) > 0 {
-		args = append(args, "--entrypoint", strings.Join(cfg.Entrypoint, " "))
+	for _, vm := range cfg.VolumeMounts {
+		hostCfg.Binds = append(hostCfg.Binds, fmt.Sprintf("%s:%s%s", vm.Name, vm.MountPath, readOnlySuffix(vm.ReadOnly)))
 	}
-	args = append(args, cfg.Image)
-	args = append(args, cfg.Args...)
 
-	stdout, _, err := r.run("docker", args...)
+	var netCfg *network.NetworkingConfig
+	if cfg.Network != "" {
+		ep := &network.EndpointSettings{
+			IPAMConfig: endpointIPAM(cfg.IP, cfg.IPv6),
+			Aliases:    cfg.NetworkAliases,
+		}
+		netCfg = &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{cfg.Network: ep}}
+	}
+
+	created, err := cli.ContainerCreate(ctx, containerCfg, hostCfg, netCfg, nil, cfg.Name)
 	if err != nil {
-		return "", err
+		if client.IsErrNotFound(err) {
+			return "", errdefs.NeedsPull(err)
+		}
+		return "", wrapErr(err)
+	}
+	if err := cli.ContainerStart(ctx, created.ID, types.ContainerStartOptions{}); err != nil {
+		return "", wrapErr(err)
+	}
+	r.logger.Tracef("docker: run %s started as %s", cfg.Image, created.ID)
+	return created.ID, nil
+}
+
+func readOnlySuffix(ro bool) string {
+	if ro {
+		return ":ro"
 	}
-	return strings.TrimSpace(stdout), nil
+	return ""
+}
+
+func endpointIPAM(ipv4, ipv6 string) *network.EndpointIPAMConfig {
+	if ipv4 == "" && ipv6 == "" {
+		return nil
+	}
+	return &network.EndpointIPAMConfig{IPv4Address: ipv4, IPv6Address: ipv6}
+}
+
+func envSlice(env map[string]string) []string {
+	out := make([]string, 0, len(env))
+	for k, v := range env {
+		out = append(out, fmt.Sprintf("%s=%s", k, v))
+	}
+	return out
 }
 
 func (r *Runner) Exec(containerID string, cmd []string) (int, string, string, error) {
-	args := append([]string{"exec", containerID}, cmd...)
-	stdout, stderr, err := r.run("docker", args...)
+	r.logger.Debugf("docker: exec %s: %v", containerID, cmd)
+	cli, err := r.client()
+	if err != nil {
+		return 0, "", "", err
+	}
+	ctx := context.Background()
+	execID, err := cli.ContainerExecCreate(ctx, containerID, types.ExecConfig{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
 	if err != nil {
-		return exitCode(err), stdout, stderr, err
+		return 0, "", "", wrapErr(err)
 	}
-	return 0, stdout, stderr, nil
+	attach, err := cli.ContainerExecAttach(ctx, execID.ID, types.ExecStartCheck{})
+	if err != nil {
+		return 0, "", "", wrapErr(err)
+	}
+	defer attach.Close()
+
+	var stdout, stderr strings.Builder
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, attach.Reader); err != nil {
+		return 0, stdout.String(), stderr.String(), errdefs.System(err)
+	}
+	inspect, err := cli.ContainerExecInspect(ctx, execID.ID)
+	if err != nil {
+		return 0, stdout.String(), stderr.String(), wrapErr(err)
+	}
+	r.logger.Tracef("docker: exec %s %v -> code=%d stdout=%q stderr=%q", containerID, cmd, inspect.ExitCode, stdout.String(), stderr.String())
+	return inspect.ExitCode, stdout.String(), stderr.String(), nil
 }
 
-func (r *Runner) Stop(containerID string) error {
-	_, _, err := r.run("docker", "stop", containerID)
+// LogsOptions configures LogsStream's "docker logs" invocation.
+type LogsOptions struct {
+	Follow bool
+	Since  string
+	Tail   string
+}
+
+// ExecStream runs cmd in containerID with stdin/stdout/stderr wired live
+// instead of buffered, so long-running or interactive commands can be
+// observed as they run rather than only after they exit. Cancelling ctx
+// kills the exec process.
+func (r *Runner) ExecStream(ctx context.Context, containerID string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	cli, err := r.client()
+	if err != nil {
+		return 0, err
+	}
+	execID, err := cli.ContainerExecCreate(ctx, containerID, types.ExecConfig{
+		Cmd:          cmd,
+		AttachStdin:  stdin != nil,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return 0, wrapErr(err)
+	}
+	attach, err := cli.ContainerExecAttach(ctx, execID.ID, types.ExecStartCheck{})
+	if err != nil {
+		return 0, wrapErr(err)
+	}
+	defer attach.Close()
+
+	if stdin != nil {
+		go func() {
+			_, _ = io.Copy(attach.Conn, stdin)
+			_ = attach.CloseWrite()
+		}()
+	}
+
+	copyErr := make(chan error, 1)
+	go func() {
+		_, err := stdcopy.StdCopy(stdout, stderr, attach.Reader)
+		copyErr <- err
+	}()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case err := <-copyErr:
+		if err != nil {
+			return 0, errdefs.System(err)
+		}
+	}
+	inspect, err := cli.ContainerExecInspect(ctx, execID.ID)
+	if err != nil {
+		return 0, wrapErr(err)
+	}
+	return inspect.ExitCode, nil
+}
+
+// ExecAttach is ExecStream's lower-level counterpart: instead of copying
+// into caller-supplied writers, it hands back the raw demultiplexed stdout
+// and stderr streams plus a channel that receives the exit code once the
+// exec completes, for callers that want to read incrementally on their own
+// terms (e.g. relaying over a different transport) rather than owning a
+// writer up front.
+func (r *Runner) ExecAttach(ctx context.Context, containerID string, cmd []string) (stdout, stderr io.ReadCloser, exitCode <-chan int, err error) {
+	cli, clientErr := r.client()
+	if clientErr != nil {
+		return nil, nil, nil, clientErr
+	}
+	execID, createErr := cli.ContainerExecCreate(ctx, containerID, types.ExecConfig{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if createErr != nil {
+		return nil, nil, nil, wrapErr(createErr)
+	}
+	attach, attachErr := cli.ContainerExecAttach(ctx, execID.ID, types.ExecStartCheck{})
+	if attachErr != nil {
+		return nil, nil, nil, wrapErr(attachErr)
+	}
+
+	outR, outW := io.Pipe()
+	errR, errW := io.Pipe()
+	codeCh := make(chan int, 1)
+	go func() {
+		_, copyErr := stdcopy.StdCopy(outW, errW, attach.Reader)
+		outW.CloseWithError(copyErr)
+		errW.CloseWithError(copyErr)
+		attach.Close()
+		code := 0
+		if inspect, err := cli.ContainerExecInspect(context.Background(), execID.ID); err == nil {
+			code = inspect.ExitCode
+		}
+		codeCh <- code
+	}()
+	return outR, errR, codeCh, nil
+}
+
+// LogLine is one line of output from RunAttached, tagged with the stream it
+// came from so callers can tell stdout from stderr without demuxing again.
+type LogLine struct {
+	Stream string // "stdout" or "stderr"
+	Text   string
+}
+
+// RunAttached creates and starts cfg's container like Run, but instead of
+// returning once it's running, attaches to its combined output and streams
+// it line by line over the returned channel, which is closed when the
+// container's log stream ends (normally, on exit, or because ctx was
+// cancelled). The container ID is available to the caller up front via the
+// second return value so it can still be Stopped/Removed independently of
+// the stream.
+func (r *Runner) RunAttached(ctx context.Context, cfg RunConfig) (<-chan LogLine, string, error) {
+	containerID, err := r.RunContext(ctx, cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	cli, err := r.client()
+	if err != nil {
+		return nil, containerID, err
+	}
+	logs, err := cli.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true, Follow: true})
+	if err != nil {
+		return nil, containerID, wrapErr(err)
+	}
+
+	lines := make(chan LogLine, 32)
+	outR, outW := io.Pipe()
+	errR, errW := io.Pipe()
+	go func() {
+		_, copyErr := stdcopy.StdCopy(outW, errW, logs)
+		outW.CloseWithError(copyErr)
+		errW.CloseWithError(copyErr)
+	}()
+	var fanout sync.WaitGroup
+	fanout.Add(2)
+	go func() { defer fanout.Done(); fanoutLines(outR, "stdout", lines) }()
+	go func() { defer fanout.Done(); fanoutLines(errR, "stderr", lines) }()
+	go func() {
+		fanout.Wait()
+		close(lines)
+	}()
+	go func() {
+		<-ctx.Done()
+		_ = logs.Close()
+	}()
+	return lines, containerID, nil
+}
+
+func fanoutLines(r io.Reader, stream string, out chan<- LogLine) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		out <- LogLine{Stream: stream, Text: scanner.Text()}
+	}
+}
+
+// LogsStream writes containerID's logs to w as they're produced. Cancelling
+// ctx stops the stream, which matters most when Follow is set since that
+// invocation otherwise never ends on its own.
+func (r *Runner) LogsStream(ctx context.Context, containerID string, opts LogsOptions, w io.Writer) error {
+	cli, err := r.client()
+	if err != nil {
+		return err
+	}
+	logs, err := cli.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     opts.Follow,
+		Since:      opts.Since,
+		Tail:       opts.Tail,
+	})
+	if err != nil {
+		return wrapErr(err)
+	}
+	defer logs.Close()
+	_, err = stdcopy.StdCopy(w, w, logs)
+	if err != nil && ctx.Err() != nil {
+		return nil
+	}
 	return err
 }
 
+func (r *Runner) Stop(containerID string) error {
+	cli, err := r.client()
+	if err != nil {
+		return err
+	}
+	if err := cli.ContainerStop(context.Background(), containerID, container.StopOptions{}); err != nil {
+		return wrapErr(err)
+	}
+	return nil
+}
+
 func (r *Runner) Remove(containerID string) error {
-	_, _, err := r.run("docker", "rm", "-f", containerID)
-	return err
+	cli, err := r.client()
+	if err != nil {
+		return err
+	}
+	if err := cli.ContainerRemove(context.Background(), containerID, types.ContainerRemoveOptions{Force: true}); err != nil {
+		return wrapErr(err)
+	}
+	return nil
 }
 
 func (r *Runner) Wait(containerID string) (int, error) {
-	stdout, _, err := r.run("docker", "wait", containerID)
+	return r.WaitContext(context.Background(), containerID)
+}
+
+// WaitContext is Wait with an explicit ctx, so the caller gets ctx.Err()
+// back instead of blocking forever on a container that never exits.
+func (r *Runner) WaitContext(ctx context.Context, containerID string) (int, error) {
+	cli, err := r.client()
 	if err != nil {
-		return exitCode(err), err
-	}
-	codeStr := strings.TrimSpace(stdout)
-	if codeStr == "" {
-		return 0, nil
+		return 0, err
 	}
-	var code int
-	_, scanErr := fmt.Sscanf(codeStr, "%d", &code)
-	if scanErr != nil {
-		return 0, scanErr
+	statusCh, errCh := cli.ContainerWait(ctx, containerID, container.WaitConditionNotRunning)
+	select {
+	case status := <-statusCh:
+		if status.Error != nil {
+			return int(status.StatusCode), errdefs.System(fmt.Errorf("%s", status.Error.Message))
+		}
+		return int(status.StatusCode), nil
+	case err := <-errCh:
+		return 0, wrapErr(err)
+	case <-ctx.Done():
+		return 0, ctx.Err()
 	}
-	return code, nil
 }
 
 func (r *Runner) Logs(containerID string) (string, error) {
-	stdout, _, err := r.run("docker", "logs", containerID)
-	return stdout, err
+	cli, err := r.client()
+	if err != nil {
+		return "", err
+	}
+	logs, err := cli.ContainerLogs(context.Background(), containerID, types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		return "", wrapErr(err)
+	}
+	defer logs.Close()
+	var out strings.Builder
+	if _, err := stdcopy.StdCopy(&out, &out, logs); err != nil {
+		return out.String(), errdefs.System(err)
+	}
+	return out.String(), nil
+}
+
+// LogsFollow streams a container's combined stdout/stderr as they are
+// written, for live parsing rather than the batch read Logs does. The
+// returned ReadCloser must be closed to stop the underlying log stream.
+func (r *Runner) LogsFollow(containerID string) (io.ReadCloser, error) {
+	cli, err := r.client()
+	if err != nil {
+		return nil, err
+	}
+	logs, err := cli.ContainerLogs(context.Background(), containerID, types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true, Follow: true})
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		_, copyErr := stdcopy.StdCopy(pw, pw, logs)
+		logs.Close()
+		pw.CloseWithError(copyErr)
+	}()
+	return pr, nil
+}
+
+// StreamLogs follows containerID's combined stdout/stderr like LogsFollow,
+// but returns pre-split lines instead of a raw reader so callers don't have
+// to scan it themselves. LogsFollow merges both streams into one reader, so
+// every line is tagged "stdout"; stderr lines are not told apart. The
+// channel is closed once the container's log stream ends.
+func (r *Runner) StreamLogs(containerID string) (<-chan LogLine, error) {
+	reader, err := r.LogsFollow(containerID)
+	if err != nil {
+		return nil, err
+	}
+	lines := make(chan LogLine, 32)
+	go func() {
+		defer close(lines)
+		defer reader.Close()
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			lines <- LogLine{Stream: "stdout", Text: scanner.Text()}
+		}
+	}()
+	return lines, nil
+}
+
+// ListContainers returns the IDs of all containers (running or stopped)
+// carrying labelKey, e.g. "labu.suite", for crash-recovery GC.
+func (r *Runner) ListContainers(labelKey string) ([]string, error) {
+	cli, err := r.client()
+	if err != nil {
+		return nil, err
+	}
+	containers, err := cli.ContainerList(context.Background(), types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", labelKey)),
+	})
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	ids := make([]string, 0, len(containers))
+	for _, c := range containers {
+		ids = append(ids, c.ID)
+	}
+	return ids, nil
 }
 
 func (r *Runner) CreateNetwork(name string) error {
-	_, _, err := r.run("docker", "network", "create", name)
-	return err
+	return r.CreateNetworkOpts(name, NetworkOptions{})
+}
+
+// CreateNetworkOpts creates a network with an explicit subnet/gateway/
+// driver, for tests that need realistic multi-subnet topologies rather
+// than one flat bridge.
+func (r *Runner) CreateNetworkOpts(name string, opts NetworkOptions) error {
+	cli, err := r.client()
+	if err != nil {
+		return err
+	}
+	create := types.NetworkCreate{Driver: opts.Driver}
+	if opts.Subnet != "" || opts.Gateway != "" {
+		create.IPAM = &network.IPAM{Config: []network.IPAMConfig{{Subnet: opts.Subnet, Gateway: opts.Gateway}}}
+	}
+	if _, err := cli.NetworkCreate(context.Background(), name, create); err != nil {
+		return wrapErr(err)
+	}
+	return nil
+}
+
+// CreateInternalNetwork creates a bridge network with no outbound
+// connectivity (docker's --internal flag): containers attached to it can
+// reach each other but not the outside world.
+func (r *Runner) CreateInternalNetwork(name string) error {
+	cli, err := r.client()
+	if err != nil {
+		return err
+	}
+	if _, err := cli.NetworkCreate(context.Background(), name, types.NetworkCreate{Internal: true}); err != nil {
+		return wrapErr(err)
+	}
+	return nil
+}
+
+// CreateEgressAllowlistNetwork creates a bridge network whose containers can
+// reach each other and the CIDRs in allowCIDRs, but nothing else outside the
+// host. Docker's network API has no CIDR-scoped variant of --internal, so
+// this is enforced with DOCKER-USER iptables rules scoped to the network's
+// assigned subnet instead: one ACCEPT per allowed CIDR (plus one for
+// intra-network traffic), then a catch-all DROP. The rules are removed by
+// RemoveNetwork when it's given this network's name.
+func (r *Runner) CreateEgressAllowlistNetwork(name string, allowCIDRs []string) error {
+	cli, err := r.client()
+	if err != nil {
+		return err
+	}
+	if _, err := cli.NetworkCreate(context.Background(), name, types.NetworkCreate{}); err != nil {
+		return wrapErr(err)
+	}
+	inspect, err := cli.NetworkInspect(context.Background(), name, types.NetworkInspectOptions{})
+	if err != nil {
+		return wrapErr(err)
+	}
+	if len(inspect.IPAM.Config) == 0 || inspect.IPAM.Config[0].Subnet == "" {
+		return errdefs.System(fmt.Errorf("egress allowlist network %s: daemon assigned no subnet", name))
+	}
+	subnet := inspect.IPAM.Config[0].Subnet
+	if err := applyEgressAllowlistRules(subnet, allowCIDRs); err != nil {
+		_ = cli.NetworkRemove(context.Background(), name)
+		return err
+	}
+	r.egressMu.Lock()
+	r.egressRules[name] = egressAllowlistRule{subnet: subnet, allowCIDRs: allowCIDRs}
+	r.egressMu.Unlock()
+	return nil
+}
+
+// applyEgressAllowlistRules appends DOCKER-USER rules accepting traffic from
+// subnet to itself and to each of allowCIDRs, followed by a DROP of
+// everything else from subnet. Order matters: iptables evaluates DOCKER-USER
+// top to bottom, so the DROP must be appended last.
+func applyEgressAllowlistRules(subnet string, allowCIDRs []string) error {
+	dests := append([]string{subnet}, allowCIDRs...)
+	for _, dest := range dests {
+		if err := runIptables("-A", "DOCKER-USER", "-s", subnet, "-d", dest, "-j", "ACCEPT"); err != nil {
+			_ = removeEgressAllowlistRules(subnet, allowCIDRs)
+			return err
+		}
+	}
+	if err := runIptables("-A", "DOCKER-USER", "-s", subnet, "-j", "DROP"); err != nil {
+		_ = removeEgressAllowlistRules(subnet, allowCIDRs)
+		return err
+	}
+	return nil
+}
+
+// removeEgressAllowlistRules deletes the rules applyEgressAllowlistRules
+// added for subnet. Each is best-effort: a rule that's already gone (or
+// never got created because an earlier one in the same call failed) isn't
+// reported as an error, since the goal is "no rules left behind", not "every
+// deletion succeeded".
+func removeEgressAllowlistRules(subnet string, allowCIDRs []string) error {
+	dests := append([]string{subnet}, allowCIDRs...)
+	for _, dest := range dests {
+		_ = runIptables("-D", "DOCKER-USER", "-s", subnet, "-d", dest, "-j", "ACCEPT")
+	}
+	_ = runIptables("-D", "DOCKER-USER", "-s", subnet, "-j", "DROP")
+	return nil
+}
+
+func runIptables(args ...string) error {
+	out, err := exec.Command("iptables", args...).CombinedOutput()
+	if err != nil {
+		return errdefs.System(fmt.Errorf("iptables %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out))))
+	}
+	return nil
 }
 
 func (r *Runner) RemoveNetwork(name string) error {
-	_, _, err := r.run("docker", "network", "rm", name)
-	return err
+	r.egressMu.Lock()
+	rule, hasEgressRules := r.egressRules[name]
+	delete(r.egressRules, name)
+	r.egressMu.Unlock()
+	if hasEgressRules {
+		_ = removeEgressAllowlistRules(rule.subnet, rule.allowCIDRs)
+	}
+
+	cli, err := r.client()
+	if err != nil {
+		return err
+	}
+	if err := cli.NetworkRemove(context.Background(), name); err != nil {
+		return wrapErr(err)
+	}
+	return nil
 }
 
 func (r *Runner) ConnectNetwork(name, containerID string) error {
-	_, _, err := r.run("docker", "network", "connect", name, containerID)
-	return err
+	return r.ConnectNetworkEndpoint(name, containerID, NetworkEndpoint{})
+}
+
+// ConnectNetworkEndpoint joins containerID to name with a fixed IP and/or
+// aliases, for IPAM-controlled multi-network topologies.
+func (r *Runner) ConnectNetworkEndpoint(name, containerID string, ep NetworkEndpoint) error {
+	cli, err := r.client()
+	if err != nil {
+		return err
+	}
+	settings := &network.EndpointSettings{
+		IPAMConfig: endpointIPAM(ep.IPv4, ep.IPv6),
+		Aliases:    ep.Aliases,
+	}
+	if err := cli.NetworkConnect(context.Background(), name, containerID, settings); err != nil {
+		return wrapErr(err)
+	}
+	return nil
 }
 
 func (r *Runner) DisconnectNetwork(name, containerID string) error {
-	_, _, err := r.run("docker", "network", "disconnect", name, containerID)
-	return err
+	cli, err := r.client()
+	if err != nil {
+		return err
+	}
+	if err := cli.NetworkDisconnect(context.Background(), name, containerID, false); err != nil {
+		return wrapErr(err)
+	}
+	return nil
 }
 
-func (r *Runner) InspectIP(network, containerID string) (string, error) {
-	format := fmt.Sprintf("{{.NetworkSettings.Networks.%s.IPAddress}}", network)
-	stdout, _, err := r.run("docker", "inspect", "-f", format, containerID)
+// CreateVolume creates a named, persistent docker volume that survives
+// container removal, for client scenarios that need chain-data snapshots,
+// restarts, or warm-starting from a fixture.
+func (r *Runner) CreateVolume(name string, opts VolumeOptions) error {
+	cli, err := r.client()
 	if err != nil {
-		return "", err
+		return err
 	}
-	return strings.TrimSpace(stdout), nil
+	_, err = cli.VolumeCreate(context.Background(), volume.CreateOptions{
+		Name:       name,
+		Driver:     opts.Driver,
+		DriverOpts: opts.DriverOpts,
+		Labels:     opts.Labels,
+	})
+	if err != nil {
+		return wrapErr(err)
+	}
+	return nil
 }
 
-func (r *Runner) run(name string, args ...string) (string, string, error) {
-	cmd := exec.Command(name, args...)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	err := cmd.Run()
-	return stdout.String(), stderr.String(), err
+func (r *Runner) RemoveVolume(name string) error {
+	cli, err := r.client()
+	if err != nil {
+		return err
+	}
+	if err := cli.VolumeRemove(context.Background(), name, true); err != nil {
+		return wrapErr(err)
+	}
+	return nil
 }
 
-func exitCode(err error) int {
-	if err == nil {
-		return 0
+// CopyToContainer streams tarStream into containerID at dstDir via the
+// Engine API's /containers/{id}/archive PUT, so callers (e.g. injecting a
+// vectors bundle without a bind mount) can write files into a container
+// without shelling out to `docker cp`.
+func (r *Runner) CopyToContainer(containerID, dstDir string, tarStream io.Reader) error {
+	cli, err := r.client()
+	if err != nil {
+		return err
+	}
+	if err := cli.CopyToContainer(context.Background(), containerID, dstDir, tarStream, types.CopyToContainerOptions{}); err != nil {
+		return wrapErr(err)
+	}
+	return nil
+}
+
+// CopyFromContainer reads srcPath out of containerID as a tar stream via the
+// Engine API's /containers/{id}/archive GET. The caller owns the returned
+// reader and must close it.
+func (r *Runner) CopyFromContainer(containerID, srcPath string) (io.ReadCloser, error) {
+	cli, err := r.client()
+	if err != nil {
+		return nil, err
+	}
+	reader, _, err := cli.CopyFromContainer(context.Background(), containerID, srcPath)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	return reader, nil
+}
+
+// SnapshotVolume tars name's contents into destDir/<name>.tar.gz by
+// launching a short-lived, unstarted container with the volume mounted and
+// using CopyFromContainer to pull a tar stream straight from the daemon, so
+// a suite's volume state can be inspected after the nodes using it are
+// gone without needing a second bind mount for output.
+func (r *Runner) SnapshotVolume(name, destDir string) error {
+	cli, err := r.client()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		return errdefs.System(err)
+	}
+	ctx := context.Background()
+	created, err := cli.ContainerCreate(ctx,
+		&container.Config{Image: "alpine", Cmd: []string{"true"}},
+		&container.HostConfig{Binds: []string{fmt.Sprintf("%s:/volume:ro", name)}},
+		nil, nil, "")
+	if err != nil {
+		return wrapErr(err)
+	}
+	defer cli.ContainerRemove(ctx, created.ID, types.ContainerRemoveOptions{Force: true})
+
+	reader, err := r.CopyFromContainer(created.ID, "/volume")
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	out, err := os.Create(fmt.Sprintf("%s/%s.tar.gz", destDir, name))
+	if err != nil {
+		return errdefs.System(err)
+	}
+	defer out.Close()
+	gz := gzip.NewWriter(out)
+	defer gz.Close()
+	if _, err := io.Copy(gz, reader); err != nil {
+		return errdefs.System(err)
+	}
+	return nil
+}
+
+func (r *Runner) InspectIP(network, containerID string) (string, error) {
+	cli, err := r.client()
+	if err != nil {
+		return "", err
+	}
+	inspect, err := cli.ContainerInspect(context.Background(), containerID)
+	if err != nil {
+		return "", wrapErr(err)
 	}
-	if ee, ok := err.(*exec.ExitError); ok {
-		return ee.ExitCode()
+	settings, ok := inspect.NetworkSettings.Networks[network]
+	if !ok {
+		return "", nil
 	}
-	return 1
+	return settings.IPAddress, nil
 }
@@ -0,0 +1,120 @@
+// Package errdefs defines a small set of typed error interfaces that
+// internal packages return instead of bare errors.New(...) strings, so
+// callers (in particular the HTTP layer) can map an error to a response
+// without knowing anything about where it came from.
+package errdefs
+
+// ErrNotFound is implemented by errors signaling that a requested resource
+// does not exist.
+type ErrNotFound interface {
+	NotFound()
+}
+
+// ErrInvalidParameter is implemented by errors signaling that the caller
+// supplied malformed or invalid input.
+type ErrInvalidParameter interface {
+	InvalidParameter()
+}
+
+// ErrConflict is implemented by errors signaling that a request conflicts
+// with the current state of a resource.
+type ErrConflict interface {
+	Conflict()
+}
+
+// ErrUnavailable is implemented by errors signaling that a dependency (the
+// container runtime, a network) could not be reached.
+type ErrUnavailable interface {
+	Unavailable()
+}
+
+// ErrSystem is implemented by errors signaling an unexpected internal
+// failure that isn't the caller's fault.
+type ErrSystem interface {
+	System()
+}
+
+// ErrNeedsPull is implemented by errors signaling that an image referenced
+// by a request isn't present on the daemon and must be pulled before the
+// operation can proceed.
+type ErrNeedsPull interface {
+	NeedsPull()
+}
+
+type wrapped struct {
+	error
+}
+
+func (w wrapped) Unwrap() error { return w.error }
+
+type notFoundError struct{ wrapped }
+
+func (notFoundError) NotFound() {}
+
+type invalidParameterError struct{ wrapped }
+
+func (invalidParameterError) InvalidParameter() {}
+
+type conflictError struct{ wrapped }
+
+func (conflictError) Conflict() {}
+
+type unavailableError struct{ wrapped }
+
+func (unavailableError) Unavailable() {}
+
+type systemError struct{ wrapped }
+
+func (systemError) System() {}
+
+type needsPullError struct{ wrapped }
+
+func (needsPullError) NeedsPull() {}
+
+// NotFound wraps err so that errors.As(err, new(errdefs.ErrNotFound)) succeeds.
+func NotFound(err error) error {
+	if err == nil {
+		return nil
+	}
+	return notFoundError{wrapped{err}}
+}
+
+// InvalidParameter wraps err so that errors.As(err, new(errdefs.ErrInvalidParameter)) succeeds.
+func InvalidParameter(err error) error {
+	if err == nil {
+		return nil
+	}
+	return invalidParameterError{wrapped{err}}
+}
+
+// Conflict wraps err so that errors.As(err, new(errdefs.ErrConflict)) succeeds.
+func Conflict(err error) error {
+	if err == nil {
+		return nil
+	}
+	return conflictError{wrapped{err}}
+}
+
+// Unavailable wraps err so that errors.As(err, new(errdefs.ErrUnavailable)) succeeds.
+func Unavailable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return unavailableError{wrapped{err}}
+}
+
+// System wraps err so that errors.As(err, new(errdefs.ErrSystem)) succeeds.
+func System(err error) error {
+	if err == nil {
+		return nil
+	}
+	return systemError{wrapped{err}}
+}
+
+// NeedsPull wraps err so that errors.As(err, new(errdefs.ErrNeedsPull)) succeeds.
+func NeedsPull(err error) error {
+	if err == nil {
+		return nil
+	}
+	return needsPullError{wrapped{err}}
+}
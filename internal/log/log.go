@@ -0,0 +1,119 @@
+// Package log wraps slog with the Trace/Debug/Info/Warn/Error/Fatal levels
+// and Printf-style methods the rest of labu already uses via the stdlib log
+// package, so callers can switch to structured, leveled logging (with a
+// --log.format=json option for CI) without rewriting every call site's
+// message into slog's key-value form.
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// Level is an ordered verbosity; lower values are noisier. It mirrors
+// logsink.Severity's naming (that package levels parsed simulator log
+// lines; this one levels labu's own operational logging) plus Fatal, which
+// logsink has no equivalent for.
+type Level int
+
+const (
+	LevelTrace Level = iota
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+func (l Level) slogLevel() slog.Level {
+	switch l {
+	case LevelTrace:
+		return slog.Level(-8)
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelInfo:
+		return slog.LevelInfo
+	case LevelWarn:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// LevelFromSimLogLevel maps the --sim.loglevel 0-5 flag (which also
+// controls the simulator's own LABU_LOGLEVEL) onto a Level for labu's
+// operational logger, so one flag drives both.
+func LevelFromSimLogLevel(n int) Level {
+	switch {
+	case n <= 0:
+		return LevelError
+	case n == 1:
+		return LevelWarn
+	case n == 2:
+		return LevelInfo
+	case n == 3:
+		return LevelDebug
+	default:
+		return LevelTrace
+	}
+}
+
+// Format selects how Logger renders records.
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+// Logger is a thin, Printf-style wrapper around slog.Logger.
+type Logger struct {
+	base  *slog.Logger
+	level Level
+}
+
+// New builds a Logger that writes records at or above level to w in the
+// given format.
+func New(level Level, format Format, w io.Writer) *Logger {
+	opts := &slog.HandlerOptions{Level: level.slogLevel()}
+	var handler slog.Handler
+	if format == FormatJSON {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
+	}
+	return &Logger{base: slog.New(handler), level: level}
+}
+
+// Discard returns a Logger that drops everything, for callers that weren't
+// given one explicitly.
+func Discard() *Logger {
+	return New(LevelFatal, FormatText, io.Discard)
+}
+
+// With returns a child Logger that attaches the given key-value pairs to
+// every subsequent record, e.g. for tagging a client container's log lines
+// with its name.
+func (l *Logger) With(args ...any) *Logger {
+	return &Logger{base: l.base.With(args...), level: l.level}
+}
+
+func (l *Logger) log(level slog.Level, format string, args ...any) {
+	l.base.Log(context.Background(), level, fmt.Sprintf(format, args...))
+}
+
+func (l *Logger) Tracef(format string, args ...any) { l.log(slog.Level(-8), format, args...) }
+func (l *Logger) Debugf(format string, args ...any) { l.log(slog.LevelDebug, format, args...) }
+func (l *Logger) Infof(format string, args ...any)  { l.log(slog.LevelInfo, format, args...) }
+func (l *Logger) Warnf(format string, args ...any)  { l.log(slog.LevelWarn, format, args...) }
+func (l *Logger) Errorf(format string, args ...any) { l.log(slog.LevelError, format, args...) }
+
+// Fatalf logs at Error level and then exits the process, matching stdlib
+// log.Fatalf's behavior.
+func (l *Logger) Fatalf(format string, args ...any) {
+	l.log(slog.LevelError, format, args...)
+	os.Exit(1)
+}
@@ -0,0 +1,84 @@
+package logsink
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileSink writes lines to a rotating plaintext file under dir, rolling
+// over once the active file exceeds maxSize bytes or maxAge has elapsed
+// since it was opened. A zero maxSize/maxAge disables that trigger.
+type FileSink struct {
+	dir    string
+	prefix string
+
+	maxSize int64
+	maxAge  time.Duration
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+func NewFileSink(dir, prefix string, maxSize int64, maxAge time.Duration) (*FileSink, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &FileSink{dir: dir, prefix: prefix, maxSize: maxSize, maxAge: maxAge}
+	if err := s.rotate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink) rotate() error {
+	if s.file != nil {
+		_ = s.file.Close()
+	}
+	name := fmt.Sprintf("%s-%d.log", s.prefix, time.Now().UnixNano())
+	f, err := os.Create(filepath.Join(s.dir, name))
+	if err != nil {
+		return err
+	}
+	s.file = f
+	s.size = 0
+	s.openedAt = time.Now()
+	return nil
+}
+
+func (s *FileSink) Write(line Line) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if (s.maxSize > 0 && s.size >= s.maxSize) || (s.maxAge > 0 && time.Since(s.openedAt) >= s.maxAge) {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	msg := fmt.Sprintf("%s [%s] %s\n", line.Time.Format(time.RFC3339Nano), line.Severity, line.Message)
+	n, err := s.file.WriteString(msg)
+	s.size += int64(n)
+	return err
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
+
+// Name returns the path of the file currently being written to.
+func (s *FileSink) Name() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return ""
+	}
+	return s.file.Name()
+}
@@ -0,0 +1,175 @@
+// Package logsink parses and fans out container log lines streamed live
+// from a running simulator, replacing the old read-everything-at-exit
+// behavior with structured, severity-tagged records that can be routed to
+// multiple destinations at once.
+package logsink
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Severity is an ordered log level; higher values are more severe.
+type Severity int
+
+const (
+	SeverityTrace Severity = iota
+	SeverityDebug
+	SeverityInfo
+	SeverityWarn
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityTrace:
+		return "trace"
+	case SeverityDebug:
+		return "debug"
+	case SeverityInfo:
+		return "info"
+	case SeverityWarn:
+		return "warn"
+	case SeverityError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// ParseSeverity maps a level name (case-insensitive, tolerating common
+// synonyms) to a Severity. ok is false if name wasn't recognized.
+func ParseSeverity(name string) (sev Severity, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "trace":
+		return SeverityTrace, true
+	case "debug":
+		return SeverityDebug, true
+	case "info":
+		return SeverityInfo, true
+	case "warn", "warning":
+		return SeverityWarn, true
+	case "error", "err", "fatal":
+		return SeverityError, true
+	}
+	return SeverityInfo, false
+}
+
+// Line is a single parsed log record streamed from a container.
+type Line struct {
+	Time      time.Time
+	Container string
+	Severity  Severity
+	Message   string
+	Fields    map[string]interface{}
+}
+
+// Sink receives parsed log lines. Implementations must be safe for
+// concurrent use; a container's log stream is fanned out to every
+// configured sink from a single reader goroutine.
+type Sink interface {
+	Write(Line) error
+	Close() error
+}
+
+// Parse classifies a raw container log line as JSON or plaintext and
+// extracts a severity: JSON lines honor a "level" field (falling back to
+// "msg"/"message" for the text), plaintext lines are matched against common
+// "LEVEL:" prefixes. Unrecognized lines default to info.
+func Parse(containerID, raw string) Line {
+	line := Line{Time: time.Now().UTC(), Container: containerID, Severity: SeverityInfo, Message: raw}
+
+	trimmed := strings.TrimSpace(raw)
+	if strings.HasPrefix(trimmed, "{") {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(trimmed), &fields); err == nil {
+			line.Fields = fields
+			if lvl, ok := fields["level"].(string); ok {
+				if sev, ok := ParseSeverity(lvl); ok {
+					line.Severity = sev
+				}
+			}
+			if msg, ok := fields["msg"].(string); ok {
+				line.Message = msg
+			} else if msg, ok := fields["message"].(string); ok {
+				line.Message = msg
+			}
+			return line
+		}
+	}
+
+	for _, prefix := range []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			if sev, ok := ParseSeverity(prefix); ok {
+				line.Severity = sev
+			}
+			break
+		}
+	}
+	return line
+}
+
+// Fanout writes a line to every sink at or above minSeverity. A sink write
+// error is logged to stderr rather than propagated, so one broken sink
+// cannot stop the others from receiving logs.
+func Fanout(sinks []Sink, minSeverity Severity, line Line) {
+	if line.Severity < minSeverity {
+		return
+	}
+	for _, sink := range sinks {
+		if err := sink.Write(line); err != nil {
+			fmt.Fprintf(os.Stderr, "logsink: write error: %v\n", err)
+		}
+	}
+}
+
+// RingSink keeps the last N lines in memory across all containers, so the
+// API server can serve a /logs/tail endpoint without re-reading the docker
+// log stream.
+type RingSink struct {
+	capacity int
+
+	mu    sync.Mutex
+	lines []Line
+}
+
+func NewRingSink(capacity int) *RingSink {
+	return &RingSink{capacity: capacity}
+}
+
+func (s *RingSink) Write(line Line) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines = append(s.lines, line)
+	if over := len(s.lines) - s.capacity; over > 0 {
+		s.lines = s.lines[over:]
+	}
+	return nil
+}
+
+func (s *RingSink) Close() error { return nil }
+
+// Tail returns up to limit lines (most recent last) at or above
+// minSeverity, optionally filtered to a single container.
+func (s *RingSink) Tail(container string, minSeverity Severity, limit int) []Line {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Line
+	for _, l := range s.lines {
+		if container != "" && l.Container != container {
+			continue
+		}
+		if l.Severity < minSeverity {
+			continue
+		}
+		out = append(out, l)
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out
+}
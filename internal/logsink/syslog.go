@@ -0,0 +1,54 @@
+package logsink
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// SyslogSink forwards lines as RFC5424 messages over UDP or TCP, for
+// consumption by a CI-side syslog collector.
+type SyslogSink struct {
+	conn     net.Conn
+	facility int
+	hostname string
+	appName  string
+}
+
+// NewSyslogSink dials addr over network ("udp" or "tcp") and tags every
+// message with the given facility (0-23, see RFC5424 section 6.2.1) and
+// app name.
+func NewSyslogSink(network, addr string, facility int, appName string) (*SyslogSink, error) {
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	return &SyslogSink{conn: conn, facility: facility, hostname: hostname, appName: appName}, nil
+}
+
+func severityCode(s Severity) int {
+	switch s {
+	case SeverityError:
+		return 3 // error
+	case SeverityWarn:
+		return 4 // warning
+	case SeverityInfo:
+		return 6 // informational
+	default:
+		return 7 // debug
+	}
+}
+
+func (s *SyslogSink) Write(line Line) error {
+	pri := s.facility*8 + severityCode(line.Severity)
+	msg := fmt.Sprintf("<%d>1 %s %s %s - - - %s\n",
+		pri, line.Time.Format(time.RFC3339Nano), s.hostname, s.appName, line.Message)
+	_, err := s.conn.Write([]byte(msg))
+	return err
+}
+
+func (s *SyslogSink) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,58 @@
+package operations
+
+import (
+	"sync"
+	"time"
+)
+
+// Event is a single lifecycle transition pushed to /events subscribers:
+// operation state changes today, node/test state changes once those
+// call sites adopt Publish too.
+type Event struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+	Time time.Time   `json:"time"`
+}
+
+// Bus fans Events out to every active subscriber. A subscriber that doesn't
+// keep up misses the events published while its buffer is full, rather than
+// blocking publishers; it stays subscribed.
+type Bus struct {
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+func NewBus() *Bus {
+	return &Bus{subs: make(map[chan Event]struct{})}
+}
+
+// Subscribe returns a channel of future events and an unsubscribe func that
+// must be called when the subscriber is done listening.
+func (b *Bus) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 32)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		if _, ok := b.subs[ch]; ok {
+			delete(b.subs, ch)
+			close(ch)
+		}
+		b.mu.Unlock()
+	}
+	return ch, unsubscribe
+}
+
+func (b *Bus) Publish(ev Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- ev:
+		default:
+			// Subscriber too slow; drop the event rather than block publishers.
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// Package operations tracks long-running asynchronous actions (image
+// builds, network creation, and in the future bulk actions) so HTTP
+// handlers can hand back an operation ID instead of blocking for the
+// duration of the work, while callers that do want to wait can poll
+// GET /operations/{id} or block on /operations/{id}/wait.
+package operations
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Class distinguishes a plain polled task from one whose progress is better
+// observed by subscribing to GET /events.
+type Class string
+
+const (
+	ClassTask      Class = "task"
+	ClassWebsocket Class = "websocket"
+)
+
+// Status is the lifecycle state of an Operation.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSuccess   Status = "success"
+	StatusFailure   Status = "failure"
+	StatusCancelled Status = "cancelled"
+)
+
+// Operation is a handle to a single asynchronous action.
+type Operation struct {
+	ID        string                 `json:"id"`
+	Class     Class                  `json:"class"`
+	Status    Status                 `json:"status"`
+	CreatedAt time.Time              `json:"createdAt"`
+	UpdatedAt time.Time              `json:"updatedAt"`
+	Err       string                 `json:"err,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Resources map[string]interface{} `json:"resources,omitempty"`
+
+	// Cancel requests early termination of the underlying action. It is
+	// nil for operations that can't be cancelled once started.
+	Cancel func() `json:"-"`
+
+	mu   sync.Mutex
+	done chan struct{}
+}
+
+// setStatus transitions op to status, reporting whether it actually did so.
+// It's a no-op (returning false) if op already reached a terminal status, so
+// a natural completion racing a CancelOp (or vice versa) can't close
+// op.done twice.
+func (op *Operation) setStatus(status Status, err error, resources map[string]interface{}) bool {
+	op.mu.Lock()
+	if isTerminal(op.Status) {
+		op.mu.Unlock()
+		return false
+	}
+	op.Status = status
+	op.UpdatedAt = time.Now()
+	if err != nil {
+		op.Err = err.Error()
+	}
+	if resources != nil {
+		op.Resources = resources
+	}
+	op.mu.Unlock()
+	if isTerminal(status) {
+		close(op.done)
+	}
+	return true
+}
+
+func isTerminal(status Status) bool {
+	return status == StatusSuccess || status == StatusFailure || status == StatusCancelled
+}
+
+// Snapshot returns a copy of the operation's fields, safe to serialize
+// without racing concurrent updates or copying the operation's lock.
+func (op *Operation) Snapshot() Operation {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	return Operation{
+		ID:        op.ID,
+		Class:     op.Class,
+		Status:    op.Status,
+		CreatedAt: op.CreatedAt,
+		UpdatedAt: op.UpdatedAt,
+		Err:       op.Err,
+		Metadata:  op.Metadata,
+		Resources: op.Resources,
+	}
+}
+
+// Wait blocks until the operation finishes or timeout elapses (timeout <= 0
+// waits forever), returning true if it finished within the budget.
+func (op *Operation) Wait(timeout time.Duration) bool {
+	if timeout <= 0 {
+		<-op.done
+		return true
+	}
+	select {
+	case <-op.done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// Registry tracks every Operation created during the controller's lifetime
+// and publishes lifecycle transitions on its Bus.
+type Registry struct {
+	mu  sync.Mutex
+	seq int
+	ops map[string]*Operation
+
+	Bus *Bus
+}
+
+func NewRegistry() *Registry {
+	return &Registry{ops: make(map[string]*Operation), Bus: NewBus()}
+}
+
+// Start registers a new pending operation and immediately marks it running.
+// cancel may be nil if the action can't be interrupted once started.
+func (r *Registry) Start(class Class, metadata map[string]interface{}, cancel func()) *Operation {
+	r.mu.Lock()
+	r.seq++
+	id := fmt.Sprintf("op-%d", r.seq)
+	now := time.Now()
+	op := &Operation{
+		ID:        id,
+		Class:     class,
+		Status:    StatusRunning,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Metadata:  metadata,
+		Cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+	r.ops[id] = op
+	r.mu.Unlock()
+	r.Bus.Publish(Event{Type: "operation", Data: op.Snapshot(), Time: now})
+	return op
+}
+
+// Finish marks op successful (err == nil) or failed, attaching any result
+// resources (e.g. {"containerId": "..."}), and publishes the transition.
+func (r *Registry) Finish(op *Operation, err error, resources map[string]interface{}) {
+	status := StatusSuccess
+	if err != nil {
+		status = StatusFailure
+	}
+	if !op.setStatus(status, err, resources) {
+		return
+	}
+	r.Bus.Publish(Event{Type: "operation", Data: op.Snapshot(), Time: time.Now()})
+}
+
+func (r *Registry) Get(id string) (*Operation, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	op, ok := r.ops[id]
+	return op, ok
+}
+
+func (r *Registry) List() []*Operation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]*Operation, 0, len(r.ops))
+	for _, op := range r.ops {
+		out = append(out, op)
+	}
+	return out
+}
+
+// CancelOp requests cancellation of a running operation. It returns false if
+// the operation doesn't exist or has no Cancel func.
+func (r *Registry) CancelOp(id string) bool {
+	op, ok := r.Get(id)
+	if !ok || op.Cancel == nil {
+		return false
+	}
+	op.Cancel()
+	if !op.setStatus(StatusCancelled, nil, nil) {
+		return false
+	}
+	r.Bus.Publish(Event{Type: "operation", Data: op.Snapshot(), Time: time.Now()})
+	return true
+}
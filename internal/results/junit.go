@@ -0,0 +1,86 @@
+package results
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// JUnitWriter renders a suite as a <testsuite> XML document, the format CI
+// dashboards (Jenkins, GitLab, GitHub Actions) already know how to parse.
+// It has no per-test-case streaming format, so WriteTestCase is a no-op;
+// the whole file is regenerated from the accumulated suite on WriteSuite.
+type JUnitWriter struct {
+	workspace string
+}
+
+func NewJUnitWriter(workspace string) *JUnitWriter {
+	return &JUnitWriter{workspace: workspace}
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut []string      `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+func (w *JUnitWriter) WriteSuite(result SuiteResult) error {
+	suite := junitTestSuite{
+		Name:  result.Name,
+		Tests: len(result.TestCases),
+	}
+	for _, tc := range result.TestCases {
+		jtc := junitTestCase{
+			Name:      tc.Name,
+			ClassName: result.Name,
+		}
+		if !tc.SummaryResult.Pass {
+			suite.Failures++
+			jtc.Failure = &junitFailure{
+				Message: tc.SummaryResult.Status,
+				Body:    tc.SummaryResult.Details,
+			}
+		}
+		for name, client := range tc.ClientInfo {
+			if client.LogFile == "" {
+				continue
+			}
+			data, err := os.ReadFile(client.LogFile)
+			if err != nil {
+				continue
+			}
+			jtc.SystemOut = append(jtc.SystemOut, fmt.Sprintf("[%s]\n%s", name, data))
+		}
+		suite.TestCases = append(suite.TestCases, jtc)
+	}
+
+	path := filepath.Join(w.workspace, "results", fmt.Sprintf("junit-suite-%d.xml", result.ID))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append([]byte(xml.Header), data...)
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (w *JUnitWriter) WriteTestCase(suiteID int, tc TestCaseResult) error {
+	return nil
+}
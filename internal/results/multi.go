@@ -0,0 +1,31 @@
+package results
+
+// MultiWriter fans WriteSuite/WriteTestCase out to every writer it composes,
+// so e.g. the JSON snapshot, JUnit XML, and NDJSON stream can all be kept up
+// to date from a single call site. It stops and returns the first error, the
+// same way the rest of this package surfaces write failures.
+type MultiWriter struct {
+	writers []ResultWriter
+}
+
+func NewMultiWriter(writers ...ResultWriter) *MultiWriter {
+	return &MultiWriter{writers: writers}
+}
+
+func (w *MultiWriter) WriteSuite(result SuiteResult) error {
+	for _, wr := range w.writers {
+		if err := wr.WriteSuite(result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *MultiWriter) WriteTestCase(suiteID int, tc TestCaseResult) error {
+	for _, wr := range w.writers {
+		if err := wr.WriteTestCase(suiteID, tc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 
 type SummaryResult struct {
 	Pass    bool   `json:"pass"`
+	Status  string `json:"status"`
 	Details string `json:"details"`
 }
 
@@ -38,6 +39,19 @@ type SuiteResult struct {
 	TestCases      map[string]TestCaseResult `json:"testCases"`
 }
 
+// ResultWriter is implemented by every result output format labu supports.
+// WriteSuite is called with the full accumulated suite whenever it changes;
+// WriteTestCase is called once per test case as soon as it finishes, so
+// streaming formats (NDJSON) don't have to wait for the suite to end. A
+// writer that only cares about one of the two is free to no-op the other.
+type ResultWriter interface {
+	WriteSuite(result SuiteResult) error
+	WriteTestCase(suiteID int, tc TestCaseResult) error
+}
+
+// Writer renders the full suite as a single suite-{id}.json snapshot,
+// overwritten on every call. It has no incremental format to stream test
+// cases into, so WriteTestCase is a no-op.
 type Writer struct {
 	workspace string
 }
@@ -58,6 +72,10 @@ func (w *Writer) WriteSuite(result SuiteResult) error {
 	return os.WriteFile(path, data, 0o644)
 }
 
+func (w *Writer) WriteTestCase(suiteID int, tc TestCaseResult) error {
+	return nil
+}
+
 func NowRFC3339() string {
 	return time.Now().UTC().Format(time.RFC3339Nano)
 }
@@ -1,9 +1,10 @@
 package sim
 
 import (
+	"bufio"
 	"context"
 	"fmt"
-	"log"
+	"io"
 	"math/rand"
 	"net"
 	"net/http"
@@ -12,10 +13,17 @@ import (
 	"strings"
 	"time"
 
-	"github.com/tos-network/lab/internal/api"
-	"github.com/tos-network/lab/internal/controller"
-	"github.com/tos-network/lab/internal/docker"
-	"github.com/tos-network/lab/internal/results"
+	"github.com/tos-network/labu/internal/api"
+	"github.com/tos-network/labu/internal/archive"
+	"github.com/tos-network/labu/internal/auth"
+	"github.com/tos-network/labu/internal/controller"
+	"github.com/tos-network/labu/internal/docker"
+	"github.com/tos-network/labu/internal/log"
+	"github.com/tos-network/labu/internal/logsink"
+	"github.com/tos-network/labu/internal/results"
+	"github.com/tos-network/labu/labusim"
+
+	"golang.org/x/term"
 )
 
 type Options struct {
@@ -24,17 +32,90 @@ type Options struct {
 	SimulatorImage string
 	ClientImages   map[string]string
 	VectorsDir     string
-	LimitPattern   string
-	Parallelism    int
-	RandomSeed     int64
-	LogLevel       int
-	Workspace      string
-	Controller     *controller.Controller
-	ResultWriter   *results.Writer
-	DockerRunner   *docker.Runner
+	// VectorsCopy, when set, injects VectorsDir into the simulator
+	// container with CopyToContainer right after it starts instead of
+	// bind-mounting it. Useful on backends/hosts where a bind mount of
+	// the host path isn't available (e.g. a remote Docker daemon).
+	VectorsCopy  bool
+	LimitPattern string
+	Parallelism  int
+	RandomSeed   int64
+	LogLevel     int
+	Workspace    string
+	Controller   *controller.Controller
+	ResultWriter results.ResultWriter
+	DockerRunner *docker.Runner
+
+	// ProgressWriter receives periodic progress updates while the simulator
+	// container runs. Defaults to os.Stderr, but is suppressed when Stderr
+	// is not a TTY or when Silent is set.
+	ProgressWriter io.Writer
+	Silent         bool
+	// AbortTimeout bounds how long Run waits for a graceful simulator
+	// shutdown after the first SIGINT/SIGTERM/SIGQUIT before force-removing
+	// containers and the network. Defaults to 10s.
+	AbortTimeout time.Duration
+
+	// LogSinks receive parsed simulator log lines as they are streamed from
+	// the container, in addition to the default file sink and the
+	// Controller's tail ring. Nil means no extra sinks.
+	LogSinks []logsink.Sink
+	// LogLevelFilter drops lines below this severity before they reach
+	// LogSinks and the tail ring. Defaults to logsink.SeverityTrace (no
+	// filtering).
+	LogLevelFilter logsink.Severity
+
+	// TokensFile, if set, enables bearer-token auth on the control-plane
+	// API server. Empty means every request is treated as admin.
+	TokensFile string
+	// TLSCert and TLSKey, if both set, serve the control-plane API over
+	// TLS instead of plaintext.
+	TLSCert string
+	TLSKey  string
+
+	// Logger receives Run's own operational log lines (as distinct from
+	// the simulator's log stream, which goes through LogSinks). Nil means
+	// nothing is logged.
+	Logger *log.Logger
+
+	// StopNotify, if set, is called as soon as the graceful-abort path
+	// below begins (it's meant to be signal.NotifyContext's stop func).
+	// Until it's called, signal.NotifyContext keeps absorbing the signals
+	// it was registered for without relaying them anywhere, so a second
+	// Ctrl-C during the abort grace period would otherwise do nothing;
+	// calling it here lets that second signal fall through to the OS's
+	// default disposition and kill the process immediately, matching the
+	// behavior documented on Run.
+	StopNotify func()
+}
+
+const progressTick = 2 * time.Second
+
+func progressWriter(opts Options) io.Writer {
+	if opts.Silent {
+		return nil
+	}
+	if opts.ProgressWriter != nil {
+		return opts.ProgressWriter
+	}
+	if term.IsTerminal(int(os.Stderr.Fd())) {
+		return os.Stderr
+	}
+	return nil
 }
 
-func Run(opts Options) error {
+// Run executes a simulation to completion or until ctx is cancelled, which
+// the caller is expected to do on an abort signal (see cmd/lab/main.go's
+// signal.NotifyContext setup). Cancellation bounds any docker build/run
+// call still in flight (the wait on the simulator is not bound to ctx; see
+// doneCh below) and triggers the graceful-abort path below; a second
+// signal after that, since it isn't relayed to this process past the first,
+// falls through to the OS's default disposition and terminates the process
+// immediately rather than waiting out the abort grace period.
+func Run(ctx context.Context, opts Options) error {
+	if opts.Logger == nil {
+		opts.Logger = log.Discard()
+	}
 	if opts.RandomSeed == 0 {
 		opts.RandomSeed = time.Now().UnixNano()
 	}
@@ -46,7 +127,15 @@ func Run(opts Options) error {
 
 	opts.Controller.SetImageOverrides(opts.ClientImages)
 
-	server := api.New(opts.Controller, opts.ResultWriter)
+	var tokens *auth.Store
+	if opts.TokensFile != "" {
+		loaded, err := auth.Load(opts.TokensFile)
+		if err != nil {
+			return fmt.Errorf("load tokens file: %w", err)
+		}
+		tokens = loaded
+	}
+	server := api.New(opts.Controller, opts.ResultWriter, tokens)
 	ln, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
 		return err
@@ -55,8 +144,14 @@ func Run(opts Options) error {
 
 	httpServer := &http.Server{Handler: server.Handler()}
 	go func() {
-		if err := httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
-			log.Printf("api server error: %v", err)
+		var serveErr error
+		if opts.TLSCert != "" && opts.TLSKey != "" {
+			serveErr = httpServer.ServeTLS(ln, opts.TLSCert, opts.TLSKey)
+		} else {
+			serveErr = httpServer.Serve(ln)
+		}
+		if serveErr != nil && serveErr != http.ErrServerClosed {
+			opts.Logger.Errorf("api server error: %v", serveErr)
 		}
 	}()
 
@@ -65,7 +160,7 @@ func Run(opts Options) error {
 		simImage = fmt.Sprintf("lab-sim-%s", sanitize(opts.Simulator))
 		simDir := filepath.Join(opts.Workspace, "..", "simulators", opts.Simulator)
 		ctxDir, dockerfile := resolveBuildContext(simDir)
-		if err := opts.DockerRunner.Build(ctxDir, dockerfile, simImage, nil); err != nil {
+		if _, err := opts.DockerRunner.BuildContext(ctx, ctxDir, dockerfile, simImage, nil); err != nil {
 			return err
 		}
 	}
@@ -79,8 +174,8 @@ func Run(opts Options) error {
 		}
 		clientDir := filepath.Join(opts.Workspace, "..", "clients", client)
 		imageTag := fmt.Sprintf("lab-client-%s", client)
-		if err := opts.DockerRunner.Build(clientDir, "Dockerfile", imageTag, nil); err != nil {
-			log.Printf("client build failed: %s: %v", client, err)
+		if _, err := opts.DockerRunner.BuildContext(ctx, clientDir, "Dockerfile", imageTag, nil); err != nil {
+			opts.Logger.Warnf("client build failed: %s: %v", client, err)
 			continue
 		}
 		successful++
@@ -91,21 +186,32 @@ func Run(opts Options) error {
 	opts.Controller.SetClientVersions(opts.Clients)
 
 	env := map[string]string{
-		"LAB_SIMULATOR":    fmt.Sprintf("http://%s", addr),
-		"LAB_TEST_PATTERN": opts.LimitPattern,
-		"LAB_PARALLELISM":  fmt.Sprintf("%d", opts.Parallelism),
-		"LAB_RANDOM_SEED":  fmt.Sprintf("%d", opts.RandomSeed),
-		"LAB_LOGLEVEL":     fmt.Sprintf("%d", opts.LogLevel),
-		"LAB_CLIENTS":      join(opts.Clients),
+		labusim.EnvSimulator:   fmt.Sprintf("http://%s", addr),
+		labusim.EnvTestPattern: opts.LimitPattern,
+		"LABU_PARALLELISM":     fmt.Sprintf("%d", opts.Parallelism),
+		"LABU_RANDOM_SEED":     fmt.Sprintf("%d", opts.RandomSeed),
+		"LABU_LOGLEVEL":        fmt.Sprintf("%d", opts.LogLevel),
+		labusim.EnvClients:     join(opts.Clients),
 	}
 
-	mounts := []string{}
+	var mounts []docker.Mount
+	var vectorsCopyDir string
 	if opts.VectorsDir != "" {
-		env["LAB_VECTOR_DIR"] = "/vectors"
-		mounts = append(mounts, fmt.Sprintf("%s:/vectors:ro", opts.VectorsDir))
+		vectorsDir, err := resolveVectorsDir(opts.Workspace, opts.VectorsDir)
+		if err != nil {
+			return fmt.Errorf("resolving vectors: %w", err)
+		}
+		env[labusim.EnvVectorDir] = "/vectors"
+		if opts.VectorsCopy {
+			vectorsCopyDir = vectorsDir
+		} else {
+			// shared: the vectors directory is bind-mounted read-only into
+			// the simulator without relabeling it for that container alone.
+			mounts = append(mounts, docker.Mount{Source: vectorsDir, Target: "/vectors", ReadOnly: true, SELinux: docker.SELinuxShared})
+		}
 	}
 
-	containerID, err := opts.DockerRunner.Run(docker.RunConfig{
+	containerID, err := opts.DockerRunner.RunContext(ctx, docker.RunConfig{
 		Image:   simImage,
 		Env:     env,
 		Mounts:  mounts,
@@ -115,26 +221,149 @@ func Run(opts Options) error {
 		return err
 	}
 
-	// Stop simulator container when done
-	defer func() {
-		if logs, err := opts.DockerRunner.Logs(containerID); err == nil {
-			if name, werr := writeSimLog(opts.Workspace, containerID, logs); werr == nil {
-				opts.Controller.SetSimLog(name)
-			}
+	if vectorsCopyDir != "" {
+		tarStream, err := archive.Pack(vectorsCopyDir, archive.PackOptions{})
+		if err != nil {
+			return fmt.Errorf("packing vectors: %w", err)
 		}
-		_ = opts.DockerRunner.Remove(containerID)
-		_ = opts.DockerRunner.RemoveNetwork("lab-net")
-		_ = httpServer.Shutdown(context.Background())
-	}()
+		if err := opts.DockerRunner.CopyToContainer(containerID, "/vectors", tarStream); err != nil {
+			return fmt.Errorf("copying vectors into simulator: %w", err)
+		}
+	}
 
-	exitCode, err := opts.DockerRunner.Wait(containerID)
+	logDir := filepath.Join(opts.Workspace, "logs")
+	fileSink, err := logsink.NewFileSink(logDir, "simulator", 0, 0)
 	if err != nil {
 		return err
 	}
-	if exitCode != 0 {
-		return fmt.Errorf("simulator exited with code %d", exitCode)
+	opts.Controller.SetSimLog(filepath.Base(fileSink.Name()))
+	ring := logsink.NewRingSink(1000)
+	opts.Controller.SetLogRing(ring)
+	sinks := append([]logsink.Sink{fileSink, ring}, opts.LogSinks...)
+
+	var logsFollow io.ReadCloser
+	if lf, lerr := opts.DockerRunner.LogsFollow(containerID); lerr == nil {
+		logsFollow = lf
+		go streamLogs(lf, containerID, sinks, opts.LogLevelFilter)
+	} else {
+		opts.Logger.Warnf("labu: could not stream simulator logs: %v", lerr)
+	}
+
+	defer cleanup(context.Background(), opts, containerID, httpServer, logsFollow, sinks)
+
+	abortTimeout := opts.AbortTimeout
+	if abortTimeout <= 0 {
+		abortTimeout = 10 * time.Second
+	}
+	pw := progressWriter(opts)
+
+	type waitResult struct {
+		code int
+		err  error
+	}
+	// WaitContext uses its own background context, not ctx: ctx is this
+	// loop's abort signal, and doneCh must only fire on the simulator
+	// actually exiting so the select below can tell "it exited" apart from
+	// "we're aborting" instead of racing the two.
+	doneCh := make(chan waitResult, 1)
+	go func() {
+		code, err := opts.DockerRunner.WaitContext(context.Background(), containerID)
+		doneCh <- waitResult{code: code, err: err}
+	}()
+
+	ticker := time.NewTicker(progressTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if pw != nil {
+				fmt.Fprintf(pw, "labu: %d test case(s) completed\n", opts.Controller.CompletedTestCount())
+			}
+
+		case <-ctx.Done():
+			if opts.StopNotify != nil {
+				opts.StopNotify()
+			}
+			if pw != nil {
+				fmt.Fprintln(pw, "labu: aborting, waiting for simulator to shut down gracefully...")
+			}
+			_ = opts.DockerRunner.Stop(containerID)
+			select {
+			case <-doneCh:
+				if pw != nil {
+					fmt.Fprintln(pw, "labu: simulator exited during graceful shutdown")
+				}
+			case <-time.After(abortTimeout):
+				if pw != nil {
+					fmt.Fprintln(pw, "labu: grace period elapsed, forcing shutdown")
+				}
+			}
+
+			shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), abortTimeout)
+			if err := opts.Controller.Shutdown(shutdownCtx); err != nil && pw != nil {
+				fmt.Fprintf(pw, "labu: some client containers/networks may not have been removed: %v\n", err)
+			}
+			cancelShutdown()
+			return ctx.Err()
+
+		case res := <-doneCh:
+			if res.err != nil {
+				saveFailureArtifacts(opts, containerID)
+				return res.err
+			}
+			if res.code != 0 {
+				saveFailureArtifacts(opts, containerID)
+				return fmt.Errorf("simulator exited with code %d", res.code)
+			}
+			return nil
+		}
+	}
+}
+
+// streamLogs reads newline-delimited log records from r until it's closed or
+// the container stops producing output, parsing and fanning each one out to
+// sinks. It returns once r is exhausted or closed.
+func streamLogs(r io.ReadCloser, containerID string, sinks []logsink.Sink, minSeverity logsink.Severity) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		logsink.Fanout(sinks, minSeverity, logsink.Parse(containerID, scanner.Text()))
+	}
+}
+
+// cleanup stops the log stream, closes the log sinks, removes the simulator
+// container and network, and shuts down the API server. It is invoked from
+// both the normal exit path (via defer) and the abort path, and is safe to
+// call more than once.
+func cleanup(ctx context.Context, opts Options, containerID string, httpServer *http.Server, logsFollow io.Closer, sinks []logsink.Sink) {
+	if logsFollow != nil {
+		_ = logsFollow.Close()
+	}
+	for _, s := range sinks {
+		_ = s.Close()
+	}
+	_ = opts.DockerRunner.Remove(containerID)
+	_ = opts.DockerRunner.RemoveNetwork("lab-net")
+	_ = httpServer.Shutdown(ctx)
+}
+
+// saveFailureArtifacts pulls /var/log out of the simulator container into
+// workspace/artifacts/var-log before it's removed, so a failed run leaves
+// something to inspect beyond the parsed log stream. Best-effort: a failure
+// here is logged, not propagated, since the run's own error already
+// explains why it failed.
+func saveFailureArtifacts(opts Options, containerID string) {
+	reader, err := opts.DockerRunner.CopyFromContainer(containerID, "/var/log")
+	if err != nil {
+		opts.Logger.Warnf("labu: could not pull /var/log artifacts: %v", err)
+		return
+	}
+	defer reader.Close()
+	destDir := filepath.Join(opts.Workspace, "artifacts", "var-log")
+	if err := archive.Unpack(reader, destDir); err != nil {
+		opts.Logger.Warnf("labu: could not extract /var/log artifacts: %v", err)
 	}
-	return nil
 }
 
 func sanitize(s string) string {
@@ -181,13 +410,3 @@ func resolveBuildContext(simDir string) (string, string) {
 	ctxDir := filepath.Clean(filepath.Join(simDir, rel))
 	return ctxDir, filepath.Join(simDir, "Dockerfile")
 }
-
-func writeSimLog(workspace, containerID, logs string) (string, error) {
-	logDir := filepath.Join(workspace, "logs")
-	if err := os.MkdirAll(logDir, 0o755); err != nil {
-		return "", err
-	}
-	name := fmt.Sprintf("simulator-%s.log", containerID)
-	path := filepath.Join(logDir, name)
-	return name, os.WriteFile(path, []byte(logs), 0o644)
-}
@@ -0,0 +1,315 @@
+package sim
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resolveVectorsDir turns Options.VectorsDir into a single local directory
+// that can be bind-mounted at /vectors. spec is a comma-separated list of
+// bundles, where each bundle is a local directory, a local .tar/.tar.gz/.zip
+// archive, or an http(s):// URL (optionally with a "#sha256=<hex>" fragment
+// for integrity checking). Archives and downloads are cached under
+// workspace/vectors-cache/<sha256> so repeat runs skip re-fetching and
+// re-extracting. A single bundle resolves to its directory directly; more
+// than one is merged under /vectors/bundle<i>/ (bundle0, bundle1, ... in
+// spec order) via symlinks so
+// loadVectors's directory walk sees every bundle without copying files.
+func resolveVectorsDir(workspace, spec string) (string, error) {
+	bundles := splitNonEmpty(spec, ",")
+	if len(bundles) == 0 {
+		return "", nil
+	}
+
+	cacheDir := filepath.Join(workspace, "vectors-cache")
+	dirs := make([]string, 0, len(bundles))
+	for _, bundle := range bundles {
+		dir, err := resolveBundle(cacheDir, bundle)
+		if err != nil {
+			return "", fmt.Errorf("vectors bundle %q: %w", bundle, err)
+		}
+		dirs = append(dirs, dir)
+	}
+	if len(dirs) == 1 {
+		return dirs[0], nil
+	}
+
+	mergeDir := filepath.Join(cacheDir, "merged-"+hashStrings(bundles))
+	if err := os.MkdirAll(mergeDir, 0o755); err != nil {
+		return "", err
+	}
+	for i, dir := range dirs {
+		link := filepath.Join(mergeDir, fmt.Sprintf("bundle%d", i))
+		if _, err := os.Lstat(link); err == nil {
+			continue
+		}
+		if err := os.Symlink(dir, link); err != nil {
+			return "", err
+		}
+	}
+	return mergeDir, nil
+}
+
+func resolveBundle(cacheDir, bundle string) (string, error) {
+	if strings.HasPrefix(bundle, "http://") || strings.HasPrefix(bundle, "https://") {
+		url, wantSHA := splitSHAFragment(bundle)
+		archivePath, err := downloadToCache(cacheDir, url, wantSHA)
+		if err != nil {
+			return "", err
+		}
+		return extractArchiveCached(cacheDir, archivePath)
+	}
+
+	info, err := os.Stat(bundle)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return bundle, nil
+	}
+	return extractArchiveCached(cacheDir, bundle)
+}
+
+// downloadToCache fetches url into cacheDir, naming the file after its
+// content hash once downloaded. If wantSHA is non-empty, the download is
+// rejected when its hash doesn't match.
+func downloadToCache(cacheDir, url, wantSHA string) (string, error) {
+	if wantSHA != "" {
+		cached := filepath.Join(cacheDir, wantSHA+filepath.Ext(url))
+		if _, err := os.Stat(cached); err == nil {
+			return cached, nil
+		}
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download %s: unexpected status %s", url, resp.Status)
+	}
+
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(cacheDir, "download-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, h), resp.Body); err != nil {
+		return "", err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	if wantSHA != "" && sum != wantSHA {
+		return "", fmt.Errorf("download %s: sha256 mismatch: got %s, want %s", url, sum, wantSHA)
+	}
+
+	dest := filepath.Join(cacheDir, sum+filepath.Ext(url))
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// extractArchiveCached extracts a local .tar, .tar.gz/.tgz, or .zip archive
+// into cacheDir/<sha256 of contents>, reusing the extraction directory if it
+// already exists.
+func extractArchiveCached(cacheDir, archivePath string) (string, error) {
+	sum, err := sha256File(archivePath)
+	if err != nil {
+		return "", err
+	}
+	destDir := filepath.Join(cacheDir, sum)
+	if info, err := os.Stat(destDir); err == nil && info.IsDir() {
+		return destDir, nil
+	}
+	tmpDir := destDir + ".tmp"
+	_ = os.RemoveAll(tmpDir)
+	if err := os.MkdirAll(tmpDir, 0o755); err != nil {
+		return "", err
+	}
+
+	lower := strings.ToLower(archivePath)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		err = extractZip(archivePath, tmpDir)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		err = extractTar(archivePath, tmpDir, true)
+	case strings.HasSuffix(lower, ".tar"):
+		err = extractTar(archivePath, tmpDir, false)
+	default:
+		err = fmt.Errorf("unrecognized archive format: %s", archivePath)
+	}
+	if err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return "", err
+	}
+	if err := os.Rename(tmpDir, destDir); err != nil {
+		return "", err
+	}
+	return destDir, nil
+}
+
+func extractTar(archivePath, destDir string, gzipped bool) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if gzipped {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target, err := safeJoin(destDir, hdr.Name)
+		if err != nil {
+			return err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, tr); err != nil {
+				out.Close()
+				return err
+			}
+			out.Close()
+		}
+	}
+}
+
+func extractZip(archivePath, destDir string) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		target, err := safeJoin(destDir, f.Name)
+		if err != nil {
+			return err
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+		if err != nil {
+			rc.Close()
+			return err
+		}
+		_, copyErr := io.Copy(out, rc)
+		out.Close()
+		rc.Close()
+		if copyErr != nil {
+			return copyErr
+		}
+	}
+	return nil
+}
+
+// safeJoin joins dir and name, rejecting archive entries that would escape
+// dir via ".." path components (a zip/tar slip).
+func safeJoin(dir, name string) (string, error) {
+	target := filepath.Join(dir, name)
+	if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) && target != filepath.Clean(dir) {
+		return "", fmt.Errorf("archive entry escapes destination: %s", name)
+	}
+	return target, nil
+}
+
+func splitSHAFragment(url string) (plainURL, sha256Hex string) {
+	if idx := strings.Index(url, "#"); idx != -1 {
+		fragment := url[idx+1:]
+		plainURL = url[:idx]
+		if strings.HasPrefix(fragment, "sha256=") {
+			sha256Hex = strings.TrimPrefix(fragment, "sha256=")
+		}
+		return plainURL, sha256Hex
+	}
+	return url, ""
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashStrings(items []string) string {
+	h := sha256.New()
+	for _, item := range items {
+		io.WriteString(h, item)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func splitNonEmpty(s, sep string) []string {
+	var out []string
+	for _, part := range strings.Split(s, sep) {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -12,7 +12,9 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -27,6 +29,15 @@ type Sim struct {
 	BaseURL     string
 	HTTP        *http.Client
 	TestPattern *regexp.Regexp
+
+	// MaxRetries, RetryBaseDelay, and RetryMaxDelay configure the backoff
+	// createSuite/createTest/endTest/launchClient/Client.Exec use against
+	// transient failures (5xx, 408, 429), so a transient network blip
+	// during a long-running suite doesn't abort it. New seeds these from
+	// EnvMaxRetries/EnvRetryBaseMS; they can be overridden afterwards.
+	MaxRetries     int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
 }
 
 func New() *Sim {
@@ -38,13 +49,35 @@ func New() *Sim {
 	if p := os.Getenv(EnvTestPattern); p != "" {
 		re, _ = regexp.Compile(p)
 	}
+	maxRetries := defaultMaxRetries
+	if v := os.Getenv(EnvMaxRetries); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			maxRetries = n
+		}
+	}
+	baseDelay := defaultRetryBaseDelay
+	if v := os.Getenv(EnvRetryBaseMS); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			baseDelay = time.Duration(n) * time.Millisecond
+		}
+	}
 	return &Sim{
-		BaseURL:     base,
-		HTTP:        &http.Client{Timeout: 120 * time.Second},
-		TestPattern: re,
+		BaseURL:        base,
+		HTTP:           &http.Client{Timeout: 120 * time.Second},
+		TestPattern:    re,
+		MaxRetries:     maxRetries,
+		RetryBaseDelay: baseDelay,
+		RetryMaxDelay:  defaultRetryMaxDelay,
 	}
 }
 
+// transport builds the retrying transport for the Sim's current knobs; it's
+// cheap to construct so callers always see live MaxRetries/RetryBaseDelay/
+// RetryMaxDelay values rather than a snapshot taken at New.
+func (s *Sim) transport() *transport {
+	return newTransport(s.HTTP, s.MaxRetries, s.RetryBaseDelay, s.RetryMaxDelay)
+}
+
 func ClientList() []string {
 	raw := os.Getenv(EnvClients)
 	if raw == "" {
@@ -87,35 +120,110 @@ type TestSpec struct {
 }
 
 type ClientTestSpec struct {
-	Name        string
-	Description string
-	Client      string
-	Networks    []string
-	Environment map[string]string
-	Files       map[string]string
-	Run         func(*T, *Client)
+	Name           string
+	Description    string
+	Client         string
+	Networks       []string
+	Environment    map[string]string
+	Files          map[string]string
+	NetworkProfile *NetworkProfile
+	// IPAM pins the IP addresses and/or aliases a launched node gets on
+	// one of Networks, keyed by network name.
+	IPAM map[string]IPAMEndpoint
+	Run  func(*T, *Client)
+}
+
+// IPAMEndpoint mirrors controller.IPAMEndpoint for the wire format sent to
+// the controller's node-launch endpoint.
+type IPAMEndpoint struct {
+	IPv4    string   `json:"ipv4"`
+	IPv6    string   `json:"ipv6"`
+	Aliases []string `json:"aliases"`
+}
+
+// EgressPolicy mirrors controller.EgressPolicy for the wire format sent to
+// the controller's node-launch endpoint.
+type EgressPolicy string
+
+const (
+	EgressAllow     EgressPolicy = "allow"
+	EgressDeny      EgressPolicy = "deny"
+	EgressAllowlist EgressPolicy = "allowlist"
+)
+
+// NetworkProfile lets a test select DNS, extra-hosts and egress settings for
+// the client container it launches, e.g. an execution vector that needs
+// DNS-less, egress-denied networking. A nil profile keeps labu-net defaults.
+type NetworkProfile struct {
+	DNS        []string     `json:"dns"`
+	DNSSearch  []string     `json:"dnsSearch"`
+	DNSOptions []string     `json:"dnsOptions"`
+	ExtraHosts []string     `json:"extraHosts"`
+	Egress     EgressPolicy `json:"egress"`
+	AllowCIDRs []string     `json:"allowCIDRs"`
 }
 
 type T struct {
-	sim        *Sim
-	suiteID    int
-	testID     int
-	name       string
+	sim         *Sim
+	suiteID     int
+	testID      int
+	name        string
 	description string
-	failed     bool
-	details    string
+	failed      bool
+	status      string
+	details     string
+	ctx         context.Context
+}
+
+// Context returns the Context bound to this test run: Background() under
+// RunSuite, or a per-test deadline derived from
+// RunSuiteConcurrentOptions.PerTestTimeout under RunSuiteConcurrent.
+// Client.Exec uses it to cancel in-flight execs when the test's deadline
+// expires.
+func (t *T) Context() context.Context {
+	if t.ctx == nil {
+		return context.Background()
+	}
+	return t.ctx
 }
 
 func (t *T) Fail(details string) {
 	t.failed = true
+	t.status = "fail"
 	t.details = details
 }
 
 func (t *T) Failf(format string, args ...interface{}) {
 	t.failed = true
+	t.status = "fail"
+	t.details = fmt.Sprintf(format, args...)
+}
+
+// Timeout marks the test as failed with a "timeout" status, distinct from a
+// regular assertion failure, so result consumers can tell a deadline expiry
+// apart from a client bug.
+func (t *T) Timeout(details string) {
+	t.failed = true
+	t.status = "timeout"
+	t.details = details
+}
+
+func (t *T) Timeoutf(format string, args ...interface{}) {
+	t.failed = true
+	t.status = "timeout"
 	t.details = fmt.Sprintf(format, args...)
 }
 
+func (t *T) resultStatus() string {
+	if t.status != "" {
+		return t.status
+	}
+	if t.failed {
+		return "fail"
+	}
+	return "pass"
+}
+
 func (t *T) Log(details string) {
 	if t.details == "" {
 		t.details = details
@@ -125,23 +233,50 @@ func (t *T) Log(details string) {
 }
 
 func (t *T) LaunchClient(spec ClientTestSpec) (*Client, error) {
-	return t.sim.launchClient(t.suiteID, t.testID, spec)
+	client, err := t.sim.launchClient(t.suiteID, t.testID, spec)
+	if err != nil {
+		return nil, err
+	}
+	client.ctx = t.Context()
+	return client, nil
 }
 
 type Client struct {
-	sim      *Sim
-	SuiteID  int
-	TestID   int
-	ID       string
-	IP       string
-	Client   string
+	sim     *Sim
+	SuiteID int
+	TestID  int
+	ID      string
+	// IP is the address on the node's primary network (Networks[0], or
+	// the suite default when no networks were requested).
+	IP string
+	// IPs holds every network the node is attached to, keyed by network
+	// name, for tests built on multi-network topologies.
+	IPs    map[string]string
+	Client string
+	// ctx bounds Exec's request to the owning test's deadline, so a
+	// parallel test that times out doesn't leave its exec running. It is
+	// set by T.LaunchClient and by the suite runners after launchClient
+	// returns; a nil ctx falls back to
+	// context.Background().
+	ctx context.Context
 }
 
 func (c *Client) Exec(command []string) (int, string, string, error) {
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return c.ExecContext(ctx, command)
+}
+
+// ExecContext behaves like Exec but runs command under ctx instead of the
+// Client's own Context, so a caller can apply a tighter deadline than the
+// enclosing test's.
+func (c *Client) ExecContext(ctx context.Context, command []string) (int, string, string, error) {
 	payload := map[string]interface{}{"command": command}
 	body, _ := json.Marshal(payload)
 	url := fmt.Sprintf("%s/testsuite/%d/test/%d/node/%s/exec", c.sim.BaseURL, c.SuiteID, c.TestID, c.ID)
-	resp, err := c.sim.HTTP.Post(url, "application/json", bytes.NewReader(body))
+	resp, err := c.sim.transport().do(ctx, http.MethodPost, url, body, "application/json", "")
 	if err != nil {
 		return 1, "", "", err
 	}
@@ -160,6 +295,95 @@ func (c *Client) Exec(command []string) (int, string, string, error) {
 	return out.ExitCode, out.Stdout, out.Stderr, nil
 }
 
+// ExecEvent is one line of ExecStream's live output: a chunk of stdout or
+// stderr as it's produced, or a final line carrying ExitCode once the
+// command exits.
+type ExecEvent struct {
+	Stream   string `json:"stream"`
+	Data     []byte `json:"data"`
+	ExitCode *int   `json:"exitCode"`
+}
+
+// ExecStream runs command like Exec but streams its stdout/stderr live over
+// a chunked NDJSON response instead of waiting for it to exit, so a
+// long-running command (e.g. a node's own run command) surfaces logs to the
+// test writer as they happen. Cancelling ctx aborts the outgoing request and
+// fires a DELETE .../exec/{execID} so the simulator stops the exec
+// server-side too, instead of leaving it running after the caller gives up.
+func (c *Client) ExecStream(ctx context.Context, command []string) (<-chan ExecEvent, error) {
+	payload := map[string]interface{}{"command": command}
+	body, _ := json.Marshal(payload)
+	url := fmt.Sprintf("%s/testsuite/%d/test/%d/node/%s/exec/stream", c.sim.BaseURL, c.SuiteID, c.TestID, c.ID)
+
+	reqCtx, cancelReq := context.WithCancel(ctx)
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		cancelReq()
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.sim.HTTP.Do(req)
+	if err != nil {
+		cancelReq()
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		cancelReq()
+		return nil, readError(resp.Body)
+	}
+	execID := resp.Header.Get("X-Labu-Exec-Id")
+
+	events := make(chan ExecEvent)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			select {
+			case <-ctx.Done():
+				if execID != "" {
+					c.cancelExec(execID)
+				}
+				cancelReq()
+			case <-stop:
+			}
+		}()
+
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var line struct {
+				Stream   string `json:"stream"`
+				Data     []byte `json:"data"`
+				ExitCode *int   `json:"exitCode"`
+			}
+			if err := dec.Decode(&line); err != nil {
+				return
+			}
+			events <- ExecEvent{Stream: line.Stream, Data: line.Data, ExitCode: line.ExitCode}
+		}
+	}()
+	return events, nil
+}
+
+// cancelExec signals the simulator to abort a still-running ExecStream call
+// by its execID, for when ctx is cancelled client-side before the command
+// has exited on its own.
+func (c *Client) cancelExec(execID string) {
+	url := fmt.Sprintf("%s/testsuite/%d/test/%d/node/%s/exec/%s", c.sim.BaseURL, c.SuiteID, c.TestID, c.ID, execID)
+	req, err := http.NewRequest(http.MethodDelete, url, nil)
+	if err != nil {
+		return
+	}
+	resp, err := c.sim.HTTP.Do(req)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+}
+
 func NewClientFromInfo(sim *Sim, suiteID, testID int, id, ip, name string) *Client {
 	return &Client{
 		sim:     sim,
@@ -194,7 +418,7 @@ func RunSuite(sim *Sim, suite Suite) error {
 		}
 		t := &T{sim: sim, suiteID: suiteID, testID: testID, name: test.Name, description: test.Description}
 		test.Run(t)
-		if err := sim.endTest(suiteID, testID, !t.failed, t.details); err != nil {
+		if err := sim.endTest(suiteID, testID, !t.failed, t.resultStatus(), t.details); err != nil {
 			return err
 		}
 	}
@@ -211,17 +435,147 @@ func RunSuite(sim *Sim, suite Suite) error {
 		client, err := sim.launchClient(suiteID, testID, test)
 		if err != nil {
 			t.Failf("client launch failed: %v", err)
-			_ = sim.endTest(suiteID, testID, false, t.details)
+			_ = sim.endTest(suiteID, testID, false, t.resultStatus(), t.details)
 			continue
 		}
+		client.ctx = t.Context()
 		test.Run(t, client)
-		if err := sim.endTest(suiteID, testID, !t.failed, t.details); err != nil {
+		if err := sim.endTest(suiteID, testID, !t.failed, t.resultStatus(), t.details); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// RunSuiteConcurrentOptions configures RunSuiteConcurrent's worker pool.
+type RunSuiteConcurrentOptions struct {
+	// Parallelism is how many tests run at once. Values <= 1 behave like
+	// RunSuite and run tests one at a time.
+	Parallelism int
+	// PerTestTimeout bounds each test's Context (see T.Context), enforced
+	// independently of the others. Zero means no deadline.
+	PerTestTimeout time.Duration
+}
+
+// RunSuiteConcurrent runs suite like RunSuite but dispatches its tests
+// through a worker pool instead of running them one at a time, so a large
+// vector-driven suite isn't bottlenecked on launching its client containers
+// serially. Each test gets its own Context, bounded by opts.PerTestTimeout,
+// reachable from the test body via T.Context; a failed client launch fails
+// only that test and doesn't block its siblings. Result submission is
+// serialized behind a mutex and made in dispatch order, so the order tests
+// land in the results writer stays deterministic regardless of which worker
+// finishes first.
+func RunSuiteConcurrent(sim *Sim, suite Suite, opts RunSuiteConcurrentOptions) error {
+	suiteID, err := sim.createSuite(suite.Name, suite.Description)
+	if err != nil {
+		return err
+	}
+	defer sim.endSuite(suiteID)
+
+	parallelism := opts.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	jobs := make(chan func())
+	var workers sync.WaitGroup
+	for i := 0; i < parallelism; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range jobs {
+				job()
+			}
+		}()
+	}
+
+	var resultMu sync.Mutex
+	submit := func(testID int, pass bool, status, details string) error {
+		resultMu.Lock()
+		defer resultMu.Unlock()
+		return sim.endTest(suiteID, testID, pass, status, details)
+	}
+
+	var errMu sync.Mutex
+	var firstErr error
+	recordErr := func(err error) {
+		if err == nil {
+			return
+		}
+		errMu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		errMu.Unlock()
+	}
+
+	// Each job submits only after the job dispatched before it has, so
+	// results reach the controller in dispatch order.
+	prevDone := make(chan struct{})
+	close(prevDone)
+
+	for _, test := range suite.Tests {
+		if !sim.match(test.Name) {
+			continue
+		}
+		test := test
+		testID, err := sim.createTest(suiteID, test.Name, test.Description)
+		if err != nil {
+			close(jobs)
+			workers.Wait()
+			return err
+		}
+		turn, done := prevDone, make(chan struct{})
+		prevDone = done
+		jobs <- func() {
+			defer close(done)
+			ctx, cancel := WithTimeout(context.Background(), opts.PerTestTimeout)
+			defer cancel()
+			t := &T{sim: sim, suiteID: suiteID, testID: testID, name: test.Name, description: test.Description, ctx: ctx}
+			test.Run(t)
+			<-turn
+			recordErr(submit(testID, !t.failed, t.resultStatus(), t.details))
+		}
+	}
+
+	for _, test := range suite.ClientTests {
+		if !sim.match(test.Name) {
+			continue
+		}
+		test := test
+		testID, err := sim.createTest(suiteID, test.Name, test.Description)
+		if err != nil {
+			close(jobs)
+			workers.Wait()
+			return err
+		}
+		turn, done := prevDone, make(chan struct{})
+		prevDone = done
+		jobs <- func() {
+			defer close(done)
+			ctx, cancel := WithTimeout(context.Background(), opts.PerTestTimeout)
+			defer cancel()
+			t := &T{sim: sim, suiteID: suiteID, testID: testID, name: test.Name, description: test.Description, ctx: ctx}
+			client, err := sim.launchClient(suiteID, testID, test)
+			if err != nil {
+				t.Failf("client launch failed: %v", err)
+				<-turn
+				recordErr(submit(testID, false, t.resultStatus(), t.details))
+				return
+			}
+			client.ctx = ctx
+			test.Run(t, client)
+			<-turn
+			recordErr(submit(testID, !t.failed, t.resultStatus(), t.details))
+		}
+	}
+
+	close(jobs)
+	workers.Wait()
+	return firstErr
+}
+
 func (s *Sim) match(name string) bool {
 	if s.TestPattern == nil {
 		return true
@@ -232,7 +586,7 @@ func (s *Sim) match(name string) bool {
 func (s *Sim) createSuite(name, desc string) (int, error) {
 	payload := map[string]string{"name": name, "description": desc}
 	body, _ := json.Marshal(payload)
-	resp, err := s.HTTP.Post(s.BaseURL+"/testsuite", "application/json", bytes.NewReader(body))
+	resp, err := s.transport().do(context.Background(), http.MethodPost, s.BaseURL+"/testsuite", body, "application/json", nextIdempotencyKey())
 	if err != nil {
 		return 0, err
 	}
@@ -248,8 +602,8 @@ func (s *Sim) createSuite(name, desc string) (int, error) {
 }
 
 func (s *Sim) endSuite(id int) error {
-	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/testsuite/%d", s.BaseURL, id), nil)
-	resp, err := s.HTTP.Do(req)
+	url := fmt.Sprintf("%s/testsuite/%d", s.BaseURL, id)
+	resp, err := s.transport().do(context.Background(), http.MethodDelete, url, nil, "", "")
 	if err != nil {
 		return err
 	}
@@ -263,7 +617,8 @@ func (s *Sim) endSuite(id int) error {
 func (s *Sim) createTest(suiteID int, name, desc string) (int, error) {
 	payload := map[string]string{"name": name, "description": desc}
 	body, _ := json.Marshal(payload)
-	resp, err := s.HTTP.Post(fmt.Sprintf("%s/testsuite/%d/test", s.BaseURL, suiteID), "application/json", bytes.NewReader(body))
+	url := fmt.Sprintf("%s/testsuite/%d/test", s.BaseURL, suiteID)
+	resp, err := s.transport().do(context.Background(), http.MethodPost, url, body, "application/json", nextIdempotencyKey())
 	if err != nil {
 		return 0, err
 	}
@@ -278,10 +633,11 @@ func (s *Sim) createTest(suiteID int, name, desc string) (int, error) {
 	return id, nil
 }
 
-func (s *Sim) endTest(suiteID, testID int, pass bool, details string) error {
-	payload := map[string]interface{}{"pass": pass, "details": details}
+func (s *Sim) endTest(suiteID, testID int, pass bool, status, details string) error {
+	payload := map[string]interface{}{"pass": pass, "status": status, "details": details}
 	body, _ := json.Marshal(payload)
-	resp, err := s.HTTP.Post(fmt.Sprintf("%s/testsuite/%d/test/%d", s.BaseURL, suiteID, testID), "application/json", bytes.NewReader(body))
+	url := fmt.Sprintf("%s/testsuite/%d/test/%d", s.BaseURL, suiteID, testID)
+	resp, err := s.transport().do(context.Background(), http.MethodPost, url, body, "application/json", "")
 	if err != nil {
 		return err
 	}
@@ -297,9 +653,11 @@ func (s *Sim) launchClient(suiteID, testID int, spec ClientTestSpec) (*Client, e
 	buf := &bytes.Buffer{}
 	writer := multipart.NewWriter(buf)
 	cfg := map[string]interface{}{
-		"client":      spec.Client,
-		"networks":    spec.Networks,
-		"environment": spec.Environment,
+		"client":         spec.Client,
+		"networks":       spec.Networks,
+		"environment":    spec.Environment,
+		"networkProfile": spec.NetworkProfile,
+		"ipam":           spec.IPAM,
 	}
 	cfgData, _ := json.Marshal(cfg)
 	if err := writer.WriteField("config", string(cfgData)); err != nil {
@@ -317,7 +675,7 @@ func (s *Sim) launchClient(suiteID, testID int, spec ClientTestSpec) (*Client, e
 	writer.Close()
 
 	url := fmt.Sprintf("%s/testsuite/%d/test/%d/node", s.BaseURL, suiteID, testID)
-	resp, err := s.HTTP.Post(url, writer.FormDataContentType(), buf)
+	resp, err := s.transport().do(context.Background(), http.MethodPost, url, buf.Bytes(), writer.FormDataContentType(), nextIdempotencyKey())
 	if err != nil {
 		return nil, err
 	}
@@ -326,13 +684,14 @@ func (s *Sim) launchClient(suiteID, testID int, spec ClientTestSpec) (*Client, e
 		return nil, readError(resp.Body)
 	}
 	var info struct {
-		ID string `json:"id"`
-		IP string `json:"ip"`
+		ID      string            `json:"id"`
+		IPs     map[string]string `json:"ips"`
+		Network string            `json:"network"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
 		return nil, err
 	}
-	return &Client{sim: s, SuiteID: suiteID, TestID: testID, ID: info.ID, IP: info.IP, Client: spec.Client}, nil
+	return &Client{sim: s, SuiteID: suiteID, TestID: testID, ID: info.ID, IP: info.IPs[info.Network], IPs: info.IPs, Client: spec.Client}, nil
 }
 
 func applyDefaultClientFiles(spec *ClientTestSpec) {
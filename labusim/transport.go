@@ -0,0 +1,160 @@
+package labusim
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	EnvMaxRetries  = "LABU_MAX_RETRIES"
+	EnvRetryBaseMS = "LABU_RETRY_BASE_MS"
+)
+
+const (
+	defaultMaxRetries     = 3
+	defaultRetryBaseDelay = 200 * time.Millisecond
+	defaultRetryMaxDelay  = 5 * time.Second
+)
+
+// IdempotencyHeader is sent on POSTs that create a suite/test/node so the
+// simulator can recognize a retried request that actually succeeded the
+// first time and dedupe instead of creating a second resource.
+const IdempotencyHeader = "X-Labu-Idempotency-Key"
+
+// transport wraps an *http.Client with exponential-backoff retry so a
+// transient network blip during a long suite doesn't abort it outright.
+// Retried status codes are 5xx, 408 (request timeout), and 429 (rate
+// limited); a Retry-After on the response is honored over the computed
+// backoff delay.
+type transport struct {
+	http       *http.Client
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+func newTransport(client *http.Client, maxRetries int, baseDelay, maxDelay time.Duration) *transport {
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	if baseDelay <= 0 {
+		baseDelay = defaultRetryBaseDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+	return &transport{http: client, maxRetries: maxRetries, baseDelay: baseDelay, maxDelay: maxDelay}
+}
+
+// do sends method/url under ctx, retrying on transient failures. bodyBytes
+// is rebuilt into a fresh reader on every attempt, since a request body is
+// consumed after the first send; pass nil for a bodyless request.
+// idempotencyKey, when non-empty, is sent as IdempotencyHeader on every
+// attempt, so callers creating a resource should generate one key per
+// logical call and reuse it across retries.
+func (tr *transport) do(ctx context.Context, method, url string, bodyBytes []byte, contentType, idempotencyKey string) (*http.Response, error) {
+	var lastErr error
+	for attempt := 0; attempt <= tr.maxRetries; attempt++ {
+		var body io.Reader
+		if bodyBytes != nil {
+			body = bytes.NewReader(bodyBytes)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, url, body)
+		if err != nil {
+			return nil, err
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if idempotencyKey != "" {
+			req.Header.Set(IdempotencyHeader, idempotencyKey)
+		}
+
+		resp, err := tr.http.Do(req)
+		if err != nil {
+			lastErr = err
+			if attempt == tr.maxRetries {
+				return nil, err
+			}
+			if !sleep(ctx, tr.delay(attempt, 0)) {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+
+		if !isRetryableStatus(resp.StatusCode) || attempt == tr.maxRetries {
+			return resp, nil
+		}
+
+		retryAfter := retryAfterDelay(resp.Header.Get("Retry-After"))
+		lastErr = fmt.Errorf("status %d", resp.StatusCode)
+		resp.Body.Close()
+		if !sleep(ctx, tr.delay(attempt, retryAfter)) {
+			return nil, ctx.Err()
+		}
+	}
+	return nil, lastErr
+}
+
+func isRetryableStatus(code int) bool {
+	return code >= 500 || code == http.StatusRequestTimeout || code == http.StatusTooManyRequests
+}
+
+// delay returns how long to wait before the next attempt: retryAfter when
+// the server gave one, otherwise exponential backoff from baseDelay with
+// full jitter, capped at maxDelay.
+func (tr *transport) delay(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+	backoff := tr.baseDelay * time.Duration(int64(1)<<uint(attempt))
+	if backoff <= 0 || backoff > tr.maxDelay {
+		backoff = tr.maxDelay
+	}
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
+
+// retryAfterDelay parses a Retry-After header in seconds-delta form (the
+// only form the simulator API ever sends), returning zero if absent or
+// unparseable.
+func retryAfterDelay(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	secs, err := strconv.Atoi(header)
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// sleep waits for d or ctx's cancellation, whichever comes first, reporting
+// false if it was ctx that won so the caller can stop retrying immediately.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+var idempotencySeq int64
+
+// nextIdempotencyKey returns a key unique to this process, meant to be
+// generated once per logical create-resource call and reused across that
+// call's retry attempts.
+func nextIdempotencyKey() string {
+	n := atomic.AddInt64(&idempotencySeq, 1)
+	return fmt.Sprintf("%d-%d-%d", os.Getpid(), time.Now().UnixNano(), n)
+}
@@ -0,0 +1,90 @@
+package labusim
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIsRetryableStatus(t *testing.T) {
+	tests := []struct {
+		code int
+		want bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusBadRequest, false},
+		{http.StatusRequestTimeout, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusBadGateway, true},
+		{599, true},
+	}
+	for _, tt := range tests {
+		if got := isRetryableStatus(tt.code); got != tt.want {
+			t.Errorf("isRetryableStatus(%d) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestRetryAfterDelay(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"0", 0},
+		{"-1", 0},
+		{"not-a-number", 0},
+	}
+	for _, tt := range tests {
+		if got := retryAfterDelay(tt.header); got != tt.want {
+			t.Errorf("retryAfterDelay(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestTransportDelayHonorsRetryAfter(t *testing.T) {
+	tr := newTransport(nil, 3, 200*time.Millisecond, 5*time.Second)
+	got := tr.delay(0, 7*time.Second)
+	if got != 7*time.Second {
+		t.Errorf("delay with retryAfter set = %v, want 7s", got)
+	}
+}
+
+func TestTransportDelayExponentialWithCap(t *testing.T) {
+	base := 100 * time.Millisecond
+	maxDelay := 1 * time.Second
+	tr := newTransport(nil, 10, base, maxDelay)
+
+	// delay() applies full jitter, so the result is uniform on [0, backoff],
+	// where backoff doubles per attempt up to maxDelay. Assert the jittered
+	// result never exceeds the (capped) backoff for that attempt.
+	for attempt, wantBackoffCap := range map[int]time.Duration{
+		0: base,
+		1: 2 * base,
+		2: 4 * base,
+		3: 8 * base,
+		10: maxDelay, // overflowed/large attempts clamp to maxDelay
+	} {
+		for i := 0; i < 20; i++ {
+			got := tr.delay(attempt, 0)
+			if got < 0 || got > wantBackoffCap {
+				t.Errorf("attempt=%d: delay() = %v, want in [0, %v]", attempt, got, wantBackoffCap)
+			}
+		}
+	}
+}
+
+func TestNewTransportDefaults(t *testing.T) {
+	tr := newTransport(nil, -1, 0, 0)
+	if tr.maxRetries != 0 {
+		t.Errorf("negative maxRetries should clamp to 0, got %d", tr.maxRetries)
+	}
+	if tr.baseDelay != defaultRetryBaseDelay {
+		t.Errorf("baseDelay = %v, want default %v", tr.baseDelay, defaultRetryBaseDelay)
+	}
+	if tr.maxDelay != defaultRetryMaxDelay {
+		t.Errorf("maxDelay = %v, want default %v", tr.maxDelay, defaultRetryMaxDelay)
+	}
+}
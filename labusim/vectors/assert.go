@@ -0,0 +1,268 @@
+package vectors
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Assertion checks one field of an RPC response against Value, addressed by
+// a small JSONPath subset (dot-separated fields, "[n]" array indices, "$"
+// root). It exists so a vector can pin down the parts of a reply that
+// matter (e.g. $.result.blockNumber) without requiring an exact match on
+// fields like id or timestamps that legitimately vary between clients.
+type Assertion struct {
+	Path      string      `json:"path" yaml:"path"`
+	Op        string      `json:"op" yaml:"op"`
+	Value     interface{} `json:"value" yaml:"value"`
+	Tolerance float64     `json:"tolerance" yaml:"tolerance"`
+}
+
+// AssertionResult is one Assertion's outcome against a response, kept around
+// so callers can report every assertion's pass/fail rather than stopping at
+// the first failure.
+type AssertionResult struct {
+	Assertion Assertion
+	Pass      bool
+	Detail    string
+}
+
+// EvaluateAssertions runs every assertion against raw (a JSON response body)
+// in order and returns one AssertionResult per assertion.
+func EvaluateAssertions(raw []byte, assertions []Assertion) ([]AssertionResult, error) {
+	var root interface{}
+	if err := json.Unmarshal(raw, &root); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	out := make([]AssertionResult, 0, len(assertions))
+	for _, a := range assertions {
+		out = append(out, evalOne(root, a))
+	}
+	return out, nil
+}
+
+func evalOne(root interface{}, a Assertion) AssertionResult {
+	val, found := evalPath(root, a.Path)
+
+	switch a.Op {
+	case "exists":
+		return AssertionResult{a, found, fmt.Sprintf("found=%v", found)}
+	case "eq":
+		if !found {
+			return AssertionResult{a, false, fmt.Sprintf("%s not found", a.Path)}
+		}
+		pass := jsonEqual(val, a.Value)
+		return AssertionResult{a, pass, fmt.Sprintf("got=%v want=%v", val, a.Value)}
+	case "ne":
+		if !found {
+			return AssertionResult{a, false, fmt.Sprintf("%s not found", a.Path)}
+		}
+		pass := !jsonEqual(val, a.Value)
+		return AssertionResult{a, pass, fmt.Sprintf("got=%v want!=%v", val, a.Value)}
+	case "gt":
+		got, ok1 := toFloat(val)
+		want, ok2 := toFloat(a.Value)
+		if !found || !ok1 || !ok2 {
+			return AssertionResult{a, false, fmt.Sprintf("%s: non-numeric comparison (got=%v want=%v)", a.Path, val, a.Value)}
+		}
+		return AssertionResult{a, got > want, fmt.Sprintf("got=%v want>%v", got, want)}
+	case "approx":
+		got, ok1 := toFloat(val)
+		want, ok2 := toFloat(a.Value)
+		if !found || !ok1 || !ok2 {
+			return AssertionResult{a, false, fmt.Sprintf("%s: non-numeric comparison (got=%v want=%v)", a.Path, val, a.Value)}
+		}
+		diff := got - want
+		if diff < 0 {
+			diff = -diff
+		}
+		return AssertionResult{a, diff <= a.Tolerance, fmt.Sprintf("got=%v want=%v±%v", got, want, a.Tolerance)}
+	case "regex":
+		s, ok := val.(string)
+		pattern, _ := a.Value.(string)
+		if !found || !ok {
+			return AssertionResult{a, false, fmt.Sprintf("%s: not a string (got=%v)", a.Path, val)}
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return AssertionResult{a, false, fmt.Sprintf("invalid regex %q: %v", pattern, err)}
+		}
+		return AssertionResult{a, re.MatchString(s), fmt.Sprintf("got=%q pattern=%q", s, pattern)}
+	case "type":
+		want, _ := a.Value.(string)
+		got := jsonTypeOf(val)
+		if !found {
+			got = "undefined"
+		}
+		return AssertionResult{a, got == want, fmt.Sprintf("got=%s want=%s", got, want)}
+	default:
+		return AssertionResult{a, false, fmt.Sprintf("unknown op %q", a.Op)}
+	}
+}
+
+// evalPath navigates root using a dot-separated path rooted at "$", e.g.
+// "$.result.logs[0].address". Missing fields or out-of-range indices report
+// found=false rather than panicking.
+func evalPath(root interface{}, path string) (interface{}, bool) {
+	path = strings.TrimPrefix(path, "$")
+	path = strings.TrimPrefix(path, ".")
+	if path == "" {
+		return root, true
+	}
+
+	cur := root
+	for _, seg := range strings.Split(path, ".") {
+		name, indices := parseSegment(seg)
+		if name != "" {
+			m, ok := cur.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			v, ok := m[name]
+			if !ok {
+				return nil, false
+			}
+			cur = v
+		}
+		for _, idx := range indices {
+			arr, ok := cur.([]interface{})
+			if !ok || idx < 0 || idx >= len(arr) {
+				return nil, false
+			}
+			cur = arr[idx]
+		}
+	}
+	return cur, true
+}
+
+// parseSegment splits a path segment like "logs[0][1]" into its field name
+// ("logs") and the array indices that follow it.
+func parseSegment(seg string) (string, []int) {
+	var indices []int
+	name := seg
+	for {
+		start := strings.IndexByte(name, '[')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(name[start:], ']')
+		if end < 0 {
+			break
+		}
+		end += start
+		if idx, err := strconv.Atoi(name[start+1 : end]); err == nil {
+			indices = append(indices, idx)
+		}
+		name = name[:start] + name[end+1:]
+	}
+	return name, indices
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func jsonEqual(a, b interface{}) bool {
+	af, aok := toFloat(a)
+	bf, bok := toFloat(b)
+	if aok && bok {
+		return af == bf
+	}
+	ab, _ := json.Marshal(a)
+	bb, _ := json.Marshal(b)
+	return string(ab) == string(bb)
+}
+
+func jsonTypeOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return "unknown"
+	}
+}
+
+// schema is a practical subset of JSON Schema: type, required, properties,
+// and items, which covers pinning down the shape of an RPC reply without
+// pulling in a full draft-07 validator.
+type schema struct {
+	Type       string            `json:"type"`
+	Required   []string          `json:"required"`
+	Properties map[string]schema `json:"properties"`
+	Items      *schema           `json:"items"`
+}
+
+// ValidateSchema checks data against a JSON Schema subset (see schema). It
+// returns the first violation found, rather than collecting all of them,
+// since a malformed reply is usually wrong in one obvious place.
+func ValidateSchema(data []byte, rawSchema []byte) error {
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	var s schema
+	if err := json.Unmarshal(rawSchema, &s); err != nil {
+		return fmt.Errorf("unmarshal schema: %w", err)
+	}
+	return validateAgainst(root, s, "$")
+}
+
+func validateAgainst(v interface{}, s schema, path string) error {
+	if s.Type != "" {
+		if got := jsonTypeOf(v); got != s.Type {
+			return fmt.Errorf("%s: expected type %s, got %s", path, s.Type, got)
+		}
+	}
+
+	if len(s.Required) > 0 || len(s.Properties) > 0 {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected object", path)
+		}
+		for _, req := range s.Required {
+			if _, ok := m[req]; !ok {
+				return fmt.Errorf("%s: missing required field %q", path, req)
+			}
+		}
+		for name, propSchema := range s.Properties {
+			if val, ok := m[name]; ok {
+				if err := validateAgainst(val, propSchema, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if s.Items != nil {
+		arr, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected array", path)
+		}
+		for i, item := range arr {
+			if err := validateAgainst(item, *s.Items, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
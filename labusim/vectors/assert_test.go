@@ -0,0 +1,58 @@
+package vectors
+
+import "testing"
+
+func TestEvaluateAssertions(t *testing.T) {
+	raw := []byte(`{
+		"result": {
+			"blockNumber": 42,
+			"hash": "0xabc123",
+			"logs": [{"address": "0xdead"}, {"address": "0xbeef"}]
+		}
+	}`)
+
+	tests := []struct {
+		name string
+		a    Assertion
+		pass bool
+	}{
+		{"eq root field", Assertion{Path: "$.result.blockNumber", Op: "eq", Value: float64(42)}, true},
+		{"eq mismatch", Assertion{Path: "$.result.blockNumber", Op: "eq", Value: float64(41)}, false},
+		{"ne", Assertion{Path: "$.result.blockNumber", Op: "ne", Value: float64(41)}, true},
+		{"exists true", Assertion{Path: "$.result.hash", Op: "exists"}, true},
+		{"exists false", Assertion{Path: "$.result.missing", Op: "exists"}, false},
+		{"gt", Assertion{Path: "$.result.blockNumber", Op: "gt", Value: float64(10)}, true},
+		{"approx within tolerance", Assertion{Path: "$.result.blockNumber", Op: "approx", Value: float64(40), Tolerance: 5}, true},
+		{"approx outside tolerance", Assertion{Path: "$.result.blockNumber", Op: "approx", Value: float64(40), Tolerance: 1}, false},
+		{"regex match", Assertion{Path: "$.result.hash", Op: "regex", Value: "^0x[0-9a-f]+$"}, true},
+		{"regex no match", Assertion{Path: "$.result.hash", Op: "regex", Value: "^zzz$"}, false},
+		{"type number", Assertion{Path: "$.result.blockNumber", Op: "type", Value: "number"}, true},
+		{"type mismatch", Assertion{Path: "$.result.hash", Op: "type", Value: "number"}, false},
+		{"array index", Assertion{Path: "$.result.logs[1].address", Op: "eq", Value: "0xbeef"}, true},
+		{"array index out of range", Assertion{Path: "$.result.logs[5].address", Op: "exists"}, false},
+		{"unknown op", Assertion{Path: "$.result.blockNumber", Op: "bogus"}, false},
+		{"missing path non-exists op", Assertion{Path: "$.result.missing", Op: "eq", Value: "x"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := EvaluateAssertions(raw, []Assertion{tt.a})
+			if err != nil {
+				t.Fatalf("EvaluateAssertions: %v", err)
+			}
+			if len(results) != 1 {
+				t.Fatalf("expected 1 result, got %d", len(results))
+			}
+			if results[0].Pass != tt.pass {
+				t.Errorf("got pass=%v want=%v (detail=%s)", results[0].Pass, tt.pass, results[0].Detail)
+			}
+		})
+	}
+}
+
+func TestEvaluateAssertionsInvalidJSON(t *testing.T) {
+	_, err := EvaluateAssertions([]byte("not json"), []Assertion{{Path: "$", Op: "exists"}})
+	if err == nil {
+		t.Fatal("expected an error for invalid JSON")
+	}
+}
@@ -0,0 +1,54 @@
+package vectors
+
+import (
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fillerLoader understands an Ethereum-style "filler" file: a single YAML
+// document that's a map of testName -> {pre, blocks, post, expect}, expanded
+// to one Vector per key instead of a flat test_vectors list. Only the first
+// block is used as the vector's input; fillers with multi-block scenarios
+// need splitting into separate entries upstream.
+type fillerLoader struct{}
+
+type fillerEntry struct {
+	Pre    map[string]interface{} `yaml:"pre"`
+	Blocks []string               `yaml:"blocks"`
+	Post   map[string]interface{} `yaml:"post"`
+	Expect struct {
+		Success     *bool  `yaml:"success"`
+		StateDigest string `yaml:"stateDigest"`
+	} `yaml:"expect"`
+}
+
+func (fillerLoader) Load(path string) ([]Vector, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var filler map[string]fillerEntry
+	if err := yaml.Unmarshal(data, &filler); err != nil {
+		return nil, err
+	}
+
+	out := make([]Vector, 0, len(filler))
+	for name, entry := range filler {
+		var wireHex string
+		if len(entry.Blocks) > 0 {
+			wireHex = entry.Blocks[0]
+		}
+		out = append(out, Vector{
+			Name:            name,
+			Description:     "filler: " + name,
+			PreState:        entry.Pre,
+			Kind:            KindBlock,
+			WireHex:         wireHex,
+			ExpectedSuccess: entry.Expect.Success,
+			ExpectedDigest:  entry.Expect.StateDigest,
+		})
+	}
+	return out, nil
+}
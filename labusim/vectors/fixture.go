@@ -0,0 +1,52 @@
+package vectors
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fixtureLoader understands a directory-per-test fixture layout: pre_state
+// .json, block.rlp or wire.hex, and expected.json living as sibling files
+// instead of being bundled into one YAML/JSON document. It produces a
+// single Vector named after the directory.
+type fixtureLoader struct{}
+
+func (fixtureLoader) Load(dir string) ([]Vector, error) {
+	v := Vector{
+		Name:        filepath.Base(dir),
+		Description: "fixture: " + filepath.Base(dir),
+		Kind:        KindBlock,
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "pre_state.json")); err == nil {
+		if err := json.Unmarshal(data, &v.PreState); err != nil {
+			return nil, fmt.Errorf("pre_state.json: %w", err)
+		}
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "wire.hex")); err == nil {
+		v.WireHex = strings.TrimSpace(string(data))
+	} else if data, err := os.ReadFile(filepath.Join(dir, "block.rlp")); err == nil {
+		v.WireHex = hex.EncodeToString(data)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "expected.json")); err == nil {
+		var expected struct {
+			Success     *bool  `json:"success"`
+			ErrorCode   *int   `json:"error_code"`
+			StateDigest string `json:"state_digest"`
+		}
+		if err := json.Unmarshal(data, &expected); err != nil {
+			return nil, fmt.Errorf("expected.json: %w", err)
+		}
+		v.ExpectedSuccess = expected.Success
+		v.ExpectedErrorCode = expected.ErrorCode
+		v.ExpectedDigest = expected.StateDigest
+	}
+
+	return []Vector{v}, nil
+}
@@ -0,0 +1,81 @@
+package vectors
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// flatLoader understands the original {test_vectors: [...]} schema the
+// consensus and RPC simulators used before this package existed: one file
+// holding a flat list of vectors, disambiguated into KindBlock/KindRPC by
+// whether an entry's input carries an rpc payload.
+type flatLoader struct{}
+
+type flatSuite struct {
+	TestVectors []flatVector `json:"test_vectors" yaml:"test_vectors"`
+}
+
+type flatVector struct {
+	Name        string                 `json:"name" yaml:"name"`
+	Description string                 `json:"description" yaml:"description"`
+	PreState    map[string]interface{} `json:"pre_state" yaml:"pre_state"`
+	Input       struct {
+		Kind    string                 `json:"kind" yaml:"kind"`
+		WireHex string                 `json:"wire_hex" yaml:"wire_hex"`
+		RPC     map[string]interface{} `json:"rpc" yaml:"rpc"`
+		RPCURL  string                 `json:"rpc_url" yaml:"rpc_url"`
+	} `json:"input" yaml:"input"`
+	Expected struct {
+		Success        *bool           `json:"success" yaml:"success"`
+		ErrorCode      *int            `json:"error_code" yaml:"error_code"`
+		StateDigest    string          `json:"state_digest" yaml:"state_digest"`
+		Response       json.RawMessage `json:"response" yaml:"response"`
+		Assertions     []Assertion     `json:"assertions" yaml:"assertions"`
+		ResponseSchema json.RawMessage `json:"response_schema" yaml:"response_schema"`
+	} `json:"expected" yaml:"expected"`
+}
+
+func (flatLoader) Load(path string) ([]Vector, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var suite flatSuite
+	if filepath.Ext(path) == ".json" {
+		err = json.Unmarshal(data, &suite)
+	} else {
+		err = yaml.Unmarshal(data, &suite)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Vector, 0, len(suite.TestVectors))
+	for _, fv := range suite.TestVectors {
+		v := Vector{
+			Name:        fv.Name,
+			Description: fv.Description,
+			PreState:    fv.PreState,
+		}
+		if len(fv.Input.RPC) > 0 {
+			v.Kind = KindRPC
+			v.RPC = fv.Input.RPC
+			v.RPCURL = fv.Input.RPCURL
+			v.ExpectedResponse = fv.Expected.Response
+			v.ExpectedAssertions = fv.Expected.Assertions
+			v.ExpectedSchema = fv.Expected.ResponseSchema
+		} else {
+			v.Kind = KindBlock
+			v.WireHex = fv.Input.WireHex
+			v.ExpectedSuccess = fv.Expected.Success
+			v.ExpectedErrorCode = fv.Expected.ErrorCode
+			v.ExpectedDigest = fv.Expected.StateDigest
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
@@ -0,0 +1,183 @@
+// Package vectors loads test vectors for the consensus and RPC simulators
+// from whatever layout LABU_VECTOR_DIR happens to be in, instead of each
+// simulator binary walking the directory and parsing a single hard-coded
+// schema itself.
+package vectors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kind identifies which simulator a Vector targets, so a single directory of
+// fixtures can serve more than one simulator binary and LoadAll can filter
+// to the ones its caller understands.
+type Kind int
+
+const (
+	KindBlock Kind = 1 << iota
+	KindRPC
+)
+
+// Vector is the runtime-agnostic representation of one test vector,
+// whichever Loader produced it. Only the fields relevant to Kind are
+// populated.
+type Vector struct {
+	Name        string
+	Description string
+	PreState    map[string]interface{}
+	Kind        Kind
+
+	// Block-vector input/expectation (KindBlock).
+	WireHex           string
+	ExpectedSuccess   *bool
+	ExpectedErrorCode *int
+	ExpectedDigest    string
+
+	// RPC-vector input/expectation (KindRPC). ExpectedResponse is the exact-
+	// match fast path; ExpectedAssertions/ExpectedSchema let a vector pin
+	// down only the fields that matter instead of the whole reply.
+	RPC                map[string]interface{}
+	RPCURL             string
+	ExpectedResponse   json.RawMessage
+	ExpectedAssertions []Assertion
+	ExpectedSchema     json.RawMessage
+}
+
+// Loader parses one vector source layout and returns the Vectors it finds
+// at root, which is a single file for the flat and filler loaders, or a
+// fixture directory for fixtureLoader.
+type Loader interface {
+	Load(root string) ([]Vector, error)
+}
+
+// fixtureSiblings are the file names that make up one directory fixture;
+// seeing any of them under a directory is what tells LoadAll to hand that
+// directory to fixtureLoader instead of treating its files individually.
+var fixtureSiblings = map[string]bool{
+	"pre_state.json": true,
+	"block.rlp":      true,
+	"wire.hex":       true,
+	"expected.json":  true,
+}
+
+// LoadAll walks dir and loads every vector file or fixture directory it
+// recognizes, keeping only Vectors whose Kind is in kinds. An empty dir
+// returns no vectors and no error, matching the simulators' prior behavior
+// when LABU_VECTOR_DIR is unset.
+func LoadAll(dir string, kinds Kind) ([]Vector, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	var out []Vector
+	seenFixtureDirs := make(map[string]bool)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		if fixtureSiblings[d.Name()] {
+			fixtureDir := filepath.Dir(path)
+			if seenFixtureDirs[fixtureDir] {
+				return nil
+			}
+			seenFixtureDirs[fixtureDir] = true
+			vecs, err := (fixtureLoader{}).Load(fixtureDir)
+			if err != nil {
+				return fmt.Errorf("load fixture %s: %w", fixtureDir, err)
+			}
+			out = append(out, filterKind(vecs, kinds)...)
+			return nil
+		}
+
+		ext := filepath.Ext(path)
+		if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		loader, err := detectLoader(ext, data)
+		if err != nil {
+			return fmt.Errorf("detect loader for %s: %w", path, err)
+		}
+		if loader == nil {
+			return nil
+		}
+		vecs, err := loader.Load(path)
+		if err != nil {
+			return fmt.Errorf("load %s: %w", path, err)
+		}
+		out = append(out, filterKind(vecs, kinds)...)
+		return nil
+	})
+	return out, err
+}
+
+// detectLoader peeks at a file's top-level shape to pick the loader that
+// understands it: a "test_vectors" key means the flat schema, otherwise a
+// map whose values carry filler-style keys (pre/blocks/post/expect) is an
+// Ethereum-style filler. Maps matching neither are skipped rather than
+// treated as an error, since a vector directory may hold unrelated files; a
+// file whose top level does not parse as a map is returned as an error.
+func detectLoader(ext string, data []byte) (Loader, error) {
+	raw := map[string]interface{}{}
+	var err error
+	if ext == ".json" {
+		err = json.Unmarshal(data, &raw)
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := raw["test_vectors"]; ok {
+		return flatLoader{}, nil
+	}
+	if looksLikeFiller(raw) {
+		return fillerLoader{}, nil
+	}
+	return nil, nil
+}
+
+func looksLikeFiller(raw map[string]interface{}) bool {
+	for _, v := range raw {
+		entry, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, ok := entry["pre"]; ok {
+			return true
+		}
+		if _, ok := entry["blocks"]; ok {
+			return true
+		}
+		if _, ok := entry["expect"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func filterKind(vecs []Vector, kinds Kind) []Vector {
+	out := make([]Vector, 0, len(vecs))
+	for _, v := range vecs {
+		if v.Kind&kinds != 0 {
+			out = append(out, v)
+		}
+	}
+	return out
+}
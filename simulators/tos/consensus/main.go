@@ -8,30 +8,35 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"path/filepath"
-
-	"gopkg.in/yaml.v3"
+	"strings"
 
 	"github.com/tos-network/labu/labusim"
+	"github.com/tos-network/labu/labusim/vectors"
 )
 
-type VectorSuite struct {
-	TestVectors []TestVector `json:"test_vectors" yaml:"test_vectors"`
-}
+// EnvMode selects how runAgainstClient's results are judged: against each
+// vector's Expected (ModeConform), pairwise against each other
+// (ModeDifferential), or both (ModeBoth). Differential mode is what lets
+// labu flag consensus splits on vectors that don't carry a canonical answer.
+const EnvMode = "LABU_CONSENSUS_MODE"
+
+type Mode int
 
-type TestVector struct {
-	Name        string                 `json:"name" yaml:"name"`
-	Description string                 `json:"description" yaml:"description"`
-	PreState    map[string]interface{} `json:"pre_state" yaml:"pre_state"`
-	Input       struct {
-		Kind    string `json:"kind" yaml:"kind"`
-		WireHex string `json:"wire_hex" yaml:"wire_hex"`
-	} `json:"input" yaml:"input"`
-	Expected struct {
-		Success     *bool  `json:"success" yaml:"success"`
-		ErrorCode   *int   `json:"error_code" yaml:"error_code"`
-		StateDigest string `json:"state_digest" yaml:"state_digest"`
-	} `json:"expected" yaml:"expected"`
+const (
+	ModeConform Mode = iota
+	ModeDifferential
+	ModeBoth
+)
+
+func modeFromEnv() Mode {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(EnvMode))) {
+	case "differential":
+		return ModeDifferential
+	case "both":
+		return ModeBoth
+	default:
+		return ModeConform
+	}
 }
 
 type ExecResult struct {
@@ -42,8 +47,9 @@ type ExecResult struct {
 
 func main() {
 	sim := labusim.New()
+	mode := modeFromEnv()
 	vectorDir := labusim.VectorDir()
-	vectors, err := loadVectors(vectorDir)
+	vecs, err := vectors.LoadAll(vectorDir, vectors.KindBlock)
 	if err != nil {
 		panic(err)
 	}
@@ -58,7 +64,7 @@ func main() {
 		panic("LABU_CLIENTS is empty")
 	}
 
-	if len(vectors) == 0 {
+	if len(vecs) == 0 {
 		suite.Add(labusim.TestSpec{
 			Name:        "consensus/skeleton",
 			Description: "No vectors found",
@@ -68,7 +74,7 @@ func main() {
 		return
 	}
 
-	for _, vec := range vectors {
+	for _, vec := range vecs {
 		vec := vec
 		suite.Add(labusim.TestSpec{
 			Name:        "consensus/" + vec.Name,
@@ -83,16 +89,27 @@ func main() {
 					}
 					results[client] = res
 				}
-				if len(clients) == 1 {
-					if err := validateExpected(vec, results[clients[0]]); err != nil {
-						t.Failf("%s: %v", vec.Name, err)
+
+				if mode == ModeConform || mode == ModeBoth {
+					if len(clients) == 1 {
+						if err := validateExpected(vec, results[clients[0]]); err != nil {
+							t.Failf("%s: %v", vec.Name, err)
+							return
+						}
+					} else {
+						for _, name := range clients {
+							if err := validateExpected(vec, results[name]); err != nil {
+								t.Failf("%s: %v", name, err)
+								return
+							}
+						}
 					}
-					return
 				}
-				for name, res := range results {
-					if err := validateExpected(vec, res); err != nil {
-						t.Failf("%s: %v", name, err)
-						return
+
+				if mode == ModeDifferential || mode == ModeBoth {
+					logClientResults(t, clients, results)
+					if err := diffResults(clients, results); err != nil {
+						t.Failf("differential: %v", err)
 					}
 				}
 			},
@@ -102,7 +119,7 @@ func main() {
 	labusim.MustRunSuite(sim, suite)
 }
 
-func runAgainstClient(t *labusim.T, clientName string, vec TestVector) (ExecResult, error) {
+func runAgainstClient(t *labusim.T, clientName string, vec vectors.Vector) (ExecResult, error) {
 	spec := labusim.ClientTestSpec{
 		Name:        "consensus-" + vec.Name + "-" + clientName,
 		Description: "execute block vector on client",
@@ -125,71 +142,67 @@ func runAgainstClient(t *labusim.T, clientName string, vec TestVector) (ExecResu
 			return ExecResult{}, fmt.Errorf("state load: %w", err)
 		}
 	}
-	if vec.Input.WireHex == "" {
+	if vec.WireHex == "" {
 		return ExecResult{}, fmt.Errorf("block vector missing wire_hex")
 	}
 	var execRes ExecResult
-	if err := postJSON(baseURL+"/block/execute", map[string]interface{}{"wire_hex": vec.Input.WireHex}, &execRes); err != nil {
+	if err := postJSON(baseURL+"/block/execute", map[string]interface{}{"wire_hex": vec.WireHex}, &execRes); err != nil {
 		return ExecResult{}, fmt.Errorf("block execute: %w", err)
 	}
 	return execRes, nil
 }
 
-func validateExpected(vec TestVector, res ExecResult) error {
-	if vec.Expected.Success != nil {
-		if res.Success != *vec.Expected.Success {
-			return fmt.Errorf("expected success=%v, got %v", *vec.Expected.Success, res.Success)
+func validateExpected(vec vectors.Vector, res ExecResult) error {
+	if vec.ExpectedSuccess != nil {
+		if res.Success != *vec.ExpectedSuccess {
+			return fmt.Errorf("expected success=%v, got %v", *vec.ExpectedSuccess, res.Success)
 		}
 	}
-	if vec.Expected.ErrorCode != nil {
-		if res.ErrorCode != *vec.Expected.ErrorCode {
-			return fmt.Errorf("expected error_code=%d, got %d", *vec.Expected.ErrorCode, res.ErrorCode)
+	if vec.ExpectedErrorCode != nil {
+		if res.ErrorCode != *vec.ExpectedErrorCode {
+			return fmt.Errorf("expected error_code=%d, got %d", *vec.ExpectedErrorCode, res.ErrorCode)
 		}
 	}
-	if vec.Expected.StateDigest != "" && res.StateDigest != "" && vec.Expected.StateDigest != res.StateDigest {
-		return fmt.Errorf("expected state_digest=%s, got %s", vec.Expected.StateDigest, res.StateDigest)
+	if vec.ExpectedDigest != "" && res.StateDigest != "" && vec.ExpectedDigest != res.StateDigest {
+		return fmt.Errorf("expected state_digest=%s, got %s", vec.ExpectedDigest, res.StateDigest)
 	}
 	return nil
 }
 
-func loadVectors(root string) ([]TestVector, error) {
-	if root == "" {
-		return nil, nil
+// diffResults compares every client's ExecResult against clients[0],
+// reporting the first field that disagrees. It has nothing to say when
+// there's only one client to compare.
+func diffResults(clients []string, results map[string]ExecResult) error {
+	if len(clients) < 2 {
+		return nil
 	}
-	var out []TestVector
-	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return err
+	ref := results[clients[0]]
+	for _, name := range clients[1:] {
+		res := results[name]
+		if res.Success != ref.Success {
+			return fmt.Errorf("%s vs %s: success mismatch: %v != %v", clients[0], name, ref.Success, res.Success)
 		}
-		if d.IsDir() {
-			return nil
+		if res.ErrorCode != ref.ErrorCode {
+			return fmt.Errorf("%s vs %s: error_code mismatch: %d != %d", clients[0], name, ref.ErrorCode, res.ErrorCode)
 		}
-		ext := filepath.Ext(path)
-		if ext != ".yaml" && ext != ".yml" && ext != ".json" {
-			return nil
+		if res.StateDigest != "" && ref.StateDigest != "" && res.StateDigest != ref.StateDigest {
+			return fmt.Errorf("%s vs %s: state_digest mismatch: %s != %s", clients[0], name, ref.StateDigest, res.StateDigest)
 		}
-		data, err := os.ReadFile(path)
+	}
+	return nil
+}
+
+// logClientResults attaches each client's raw ExecResult to t.details as a
+// JSON blob, so a differential disagreement can be inspected without rerunning
+// the vector.
+func logClientResults(t *labusim.T, clients []string, results map[string]ExecResult) {
+	for _, name := range clients {
+		blob, err := json.Marshal(results[name])
 		if err != nil {
-			return err
+			continue
 		}
-		var suite VectorSuite
-		if ext == ".json" {
-			if err := json.Unmarshal(data, &suite); err != nil {
-				return err
-			}
-		} else {
-			if err := yaml.Unmarshal(data, &suite); err != nil {
-				return err
-			}
-		}
-		for _, vec := range suite.TestVectors {
-			if vec.Input.Kind == "block" || vec.Input.Kind == "" {
-				out = append(out, vec)
-			}
-		}
-		return nil
-	})
-	return out, err
+		t.Log(fmt.Sprintf("%s: %s", name, blob))
+	}
 }
 
 func postJSON(url string, payload interface{}, out interface{}) error {
@@ -1,6 +1,10 @@
 package main
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,6 +13,8 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -16,6 +22,82 @@ import (
 	"github.com/tos-network/labu/labusim"
 )
 
+const (
+	envVectorTimeout = "LABU_VECTOR_TIMEOUT"
+	envHealthTimeout = "LABU_HEALTH_TIMEOUT"
+
+	defaultVectorTimeout = 60 * time.Second
+	defaultHealthTimeout = 20 * time.Second
+)
+
+// ErrDeadlineExceeded is returned by the deadline-aware HTTP helpers and by
+// waitForHealth when the per-vector or per-health-check budget elapses, so
+// callers can distinguish a timeout from an ordinary request failure.
+var ErrDeadlineExceeded = errors.New("deadline exceeded")
+
+var (
+	vectorTimeout = durationEnv(envVectorTimeout, defaultVectorTimeout)
+	healthTimeout = durationEnv(envHealthTimeout, defaultHealthTimeout)
+)
+
+func durationEnv(name string, fallback time.Duration) time.Duration {
+	if raw := os.Getenv(name); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil {
+			return d
+		}
+	}
+	return fallback
+}
+
+// deadlineClient wraps an http.Client with a per-call deadline, modeled on
+// the timer+cancel-channel pattern used by gonet's deadlineTimer. Each
+// armDeadline call stops the previous call's timer, creates a fresh cancelCh
+// and, for a positive timeout, a new timer that closes cancelCh and cancels
+// the request's context when it fires. The timer is not reused across calls,
+// and a request that ends before its timer fires leaves that timer running
+// until the next armDeadline call stops it.
+type deadlineClient struct {
+	http *http.Client
+
+	mu       sync.Mutex
+	timer    *time.Timer
+	cancelCh chan struct{}
+}
+
+func newDeadlineClient() *deadlineClient {
+	return &deadlineClient{http: &http.Client{}}
+}
+
+func (d *deadlineClient) armDeadline(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+
+	d.mu.Lock()
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	cancelCh := make(chan struct{})
+	d.cancelCh = cancelCh
+	if timeout > 0 {
+		d.timer = time.AfterFunc(timeout, func() {
+			close(cancelCh)
+			cancel()
+		})
+	}
+	d.mu.Unlock()
+
+	return ctx, cancel
+}
+
+func (d *deadlineClient) do(req *http.Request, timeout time.Duration) (*http.Response, error) {
+	ctx, cancel := d.armDeadline(req.Context(), timeout)
+	defer cancel()
+	resp, err := d.http.Do(req.WithContext(ctx))
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return nil, ErrDeadlineExceeded
+	}
+	return resp, err
+}
+
 type VectorSuite struct {
 	TestVectors []TestVector `json:"test_vectors" yaml:"test_vectors"`
 }
@@ -39,6 +121,7 @@ type TestVector struct {
 	Transaction struct {
 		WireHex string `json:"wire_hex" yaml:"wire_hex"`
 	} `json:"transaction" yaml:"transaction"`
+	NetworkProfile *labusim.NetworkProfile `json:"network_profile" yaml:"network_profile"`
 }
 
 type ExecResult struct {
@@ -81,7 +164,11 @@ func main() {
 			Description: "Vector " + vec.Vector.Name,
 			Run: func(t *labusim.T) {
 				if err := runVectorCase(t, vec.Vector, clients); err != nil {
-					t.Failf("%s: %v", vec.Vector.Name, err)
+					if errors.Is(err, ErrDeadlineExceeded) {
+						t.Timeoutf("%s: %v", vec.Vector.Name, err)
+					} else {
+						t.Failf("%s: %v", vec.Vector.Name, err)
+					}
 				}
 			},
 		})
@@ -91,10 +178,16 @@ func main() {
 }
 
 func runVectorCase(t *labusim.T, vec TestVector, clients []string) error {
+	ctx, cancel := labusim.WithTimeout(context.Background(), vectorTimeout)
+	defer cancel()
+
 	results := make(map[string]ClientResult)
 	for _, client := range clients {
-		res, err := runAgainstClient(t, client, vec)
+		res, err := runAgainstClient(t, ctx, client, vec)
 		if err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("%s: %w", client, ErrDeadlineExceeded)
+			}
 			return fmt.Errorf("%s: %w", client, err)
 		}
 		results[client] = res
@@ -119,12 +212,13 @@ func runVectorCase(t *labusim.T, vec TestVector, clients []string) error {
 	return compareResults(results, clients[0])
 }
 
-func runAgainstClient(t *labusim.T, clientName string, vec TestVector) (ClientResult, error) {
+func runAgainstClient(t *labusim.T, ctx context.Context, clientName string, vec TestVector) (ClientResult, error) {
 	spec := labusim.ClientTestSpec{
-		Name:        "execution-" + vec.Name + "-" + clientName,
-		Description: "execute vector on client",
-		Client:      clientName,
-		Environment: map[string]string{},
+		Name:           "execution-" + vec.Name + "-" + clientName,
+		Description:    "execute vector on client",
+		Client:         clientName,
+		Environment:    map[string]string{},
+		NetworkProfile: vec.NetworkProfile,
 	}
 
 	client, err := t.LaunchClient(spec)
@@ -132,16 +226,17 @@ func runAgainstClient(t *labusim.T, clientName string, vec TestVector) (ClientRe
 		return ClientResult{}, err
 	}
 
+	dc := newDeadlineClient()
 	baseURL := fmt.Sprintf("http://%s:8080", client.IP)
-	if err := waitForHealth(baseURL); err != nil {
+	if err := waitForHealth(ctx, dc, baseURL); err != nil {
 		return ClientResult{}, fmt.Errorf("health check: %w", err)
 	}
 
-	if err := postJSON(baseURL+"/state/reset", map[string]interface{}{}, nil); err != nil {
+	if err := postJSON(ctx, dc, baseURL+"/state/reset", map[string]interface{}{}, nil); err != nil {
 		return ClientResult{}, fmt.Errorf("state reset: %w", err)
 	}
 	if vec.PreState != nil {
-		if err := postJSON(baseURL+"/state/load", vec.PreState, nil); err != nil {
+		if err := postJSON(ctx, dc, baseURL+"/state/load", vec.PreState, nil); err != nil {
 			return ClientResult{}, fmt.Errorf("state load: %w", err)
 		}
 	}
@@ -159,7 +254,7 @@ func runAgainstClient(t *labusim.T, clientName string, vec TestVector) (ClientRe
 	}
 	if kind == "tx_roundtrip" {
 		payload := map[string]interface{}{"wire_hex": wireHex}
-		if err := postJSON(baseURL+"/tx/roundtrip", payload, &execRes); err != nil {
+		if err := postJSON(ctx, dc, baseURL+"/tx/roundtrip", payload, &execRes); err != nil {
 			return ClientResult{}, fmt.Errorf("tx roundtrip: %w", err)
 		}
 	} else if wireHex != "" || vec.Input.Tx != nil {
@@ -170,17 +265,17 @@ func runAgainstClient(t *labusim.T, clientName string, vec TestVector) (ClientRe
 		if vec.Input.Tx != nil {
 			payload["tx"] = vec.Input.Tx
 		}
-		if err := postJSON(baseURL+"/tx/execute", payload, &execRes); err != nil {
+		if err := postJSON(ctx, dc, baseURL+"/tx/execute", payload, &execRes); err != nil {
 			return ClientResult{}, fmt.Errorf("tx execute: %w", err)
 		}
 	} else {
-		if err := getJSON(baseURL+"/state/digest", &execRes); err != nil {
+		if err := getJSON(ctx, dc, baseURL+"/state/digest", &execRes); err != nil {
 			return ClientResult{}, fmt.Errorf("state digest: %w", err)
 		}
 	}
 	var post map[string]interface{}
 	if vec.Expected.PostState != nil {
-		if err := getJSON(baseURL+"/state/export", &post); err != nil {
+		if err := getJSON(ctx, dc, baseURL+"/state/export", &post); err != nil {
 			return ClientResult{}, fmt.Errorf("state export: %w", err)
 		}
 	}
@@ -301,7 +396,20 @@ type NamedVector struct {
 	Vector TestVector
 }
 
+// envVectorArchive, when set to "1", tells loadVectors that LABU_VECTOR_DIR
+// names an archive file (.tar, .tar.gz/.tgz, or .zip) to read vectors from
+// directly rather than a directory. sim.Run does not set it: it extracts
+// archived and remote bundles on the host and mounts the resulting directory.
+const envVectorArchive = "LABU_VECTOR_ARCHIVE"
+
 func loadVectors(root string) ([]NamedVector, error) {
+	if os.Getenv(envVectorArchive) == "1" {
+		return loadVectorsFromArchive(root)
+	}
+	return loadVectorsFromDir(root)
+}
+
+func loadVectorsFromDir(root string) ([]NamedVector, error) {
 	var out []NamedVector
 	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
@@ -310,42 +418,154 @@ func loadVectors(root string) ([]NamedVector, error) {
 		if d.IsDir() {
 			return nil
 		}
-		ext := filepath.Ext(path)
-		if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+		if !isVectorFile(path) {
 			return nil
 		}
 		data, err := os.ReadFile(path)
 		if err != nil {
 			return err
 		}
-		var suite VectorSuite
-		if ext == ".json" {
-			if err := json.Unmarshal(data, &suite); err != nil {
-				return err
-			}
-		} else {
-			if err := yaml.Unmarshal(data, &suite); err != nil {
-				return err
-			}
+		vecs, err := parseVectorFile(filepath.Ext(path), data)
+		if err != nil {
+			return err
 		}
-		for _, vec := range suite.TestVectors {
-			// Skip non-execution vectors that use `input.rpc` (handled by the rpc simulator).
-			if vec.Input.RPC != nil && len(vec.Input.RPC) > 0 {
-				continue
-			}
-			out = append(out, NamedVector{
-				File:   filepath.Base(path),
-				Vector: vec,
-			})
+		for _, vec := range vecs {
+			out = append(out, NamedVector{File: filepath.Base(path), Vector: vec})
 		}
 		return nil
 	})
 	return out, err
 }
 
-func postJSON(url string, payload interface{}, out interface{}) error {
+// loadVectorsFromArchive reads vectors straight out of a .tar, .tar.gz/.tgz,
+// or .zip archive without extracting it, so File is the archive-relative
+// path (e.g. "suite1/basic.yaml") rather than just the base name.
+func loadVectorsFromArchive(archivePath string) ([]NamedVector, error) {
+	lower := strings.ToLower(archivePath)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return loadVectorsFromZip(archivePath)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return loadVectorsFromTar(archivePath, true)
+	case strings.HasSuffix(lower, ".tar"):
+		return loadVectorsFromTar(archivePath, false)
+	default:
+		return nil, fmt.Errorf("unrecognized vector archive format: %s", archivePath)
+	}
+}
+
+func loadVectorsFromTar(archivePath string, gzipped bool) ([]NamedVector, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if gzipped {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var out []NamedVector
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg || !isVectorFile(hdr.Name) {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		vecs, err := parseVectorFile(filepath.Ext(hdr.Name), data)
+		if err != nil {
+			return nil, err
+		}
+		for _, vec := range vecs {
+			out = append(out, NamedVector{File: hdr.Name, Vector: vec})
+		}
+	}
+}
+
+func loadVectorsFromZip(archivePath string) ([]NamedVector, error) {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var out []NamedVector
+	for _, zf := range zr.File {
+		if zf.FileInfo().IsDir() || !isVectorFile(zf.Name) {
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		vecs, err := parseVectorFile(filepath.Ext(zf.Name), data)
+		if err != nil {
+			return nil, err
+		}
+		for _, vec := range vecs {
+			out = append(out, NamedVector{File: zf.Name, Vector: vec})
+		}
+	}
+	return out, nil
+}
+
+func isVectorFile(name string) bool {
+	ext := filepath.Ext(name)
+	return ext == ".yaml" || ext == ".yml" || ext == ".json"
+}
+
+// parseVectorFile decodes a vector suite file and filters out non-execution
+// vectors that use input.rpc (those are handled by the rpc simulator).
+func parseVectorFile(ext string, data []byte) ([]TestVector, error) {
+	var suite VectorSuite
+	var err error
+	if ext == ".json" {
+		err = json.Unmarshal(data, &suite)
+	} else {
+		err = yaml.Unmarshal(data, &suite)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []TestVector
+	for _, vec := range suite.TestVectors {
+		if vec.Input.RPC != nil && len(vec.Input.RPC) > 0 {
+			continue
+		}
+		out = append(out, vec)
+	}
+	return out, nil
+}
+
+func postJSON(ctx context.Context, dc *deadlineClient, url string, payload interface{}, out interface{}) error {
 	body, _ := json.Marshal(payload)
-	resp, err := http.Post(url, "application/json", bytesReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytesReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := dc.do(req, 0)
 	if err != nil {
 		return err
 	}
@@ -359,8 +579,12 @@ func postJSON(url string, payload interface{}, out interface{}) error {
 	return nil
 }
 
-func getJSON(url string, out interface{}) error {
-	resp, err := http.Get(url)
+func getJSON(ctx context.Context, dc *deadlineClient, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := dc.do(req, 0)
 	if err != nil {
 		return err
 	}
@@ -379,19 +603,38 @@ func readHTTPError(r io.Reader) error {
 	return errors.New(string(b))
 }
 
-func waitForHealth(baseURL string) error {
+// waitForHealth polls baseURL+"/health" with an exponential backoff,
+// bounded both by healthTimeout and by ctx, whichever elapses first.
+func waitForHealth(ctx context.Context, dc *deadlineClient, baseURL string) error {
+	ctx, cancel := labusim.WithTimeout(ctx, healthTimeout)
+	defer cancel()
+
 	url := baseURL + "/health"
-	for i := 0; i < 20; i++ {
-		resp, err := http.Get(url)
+	backoff := 100 * time.Millisecond
+	const maxBackoff = 2 * time.Second
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 		if err == nil {
-			resp.Body.Close()
-			if resp.StatusCode/100 == 2 {
-				return nil
+			if resp, err := dc.do(req, 0); err == nil {
+				resp.Body.Close()
+				if resp.StatusCode/100 == 2 {
+					return nil
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return ErrDeadlineExceeded
 			}
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff < maxBackoff {
+			backoff *= 2
 		}
-		time.Sleep(500 * time.Millisecond)
 	}
-	return errors.New("timeout waiting for /health")
 }
 
 func bytesReader(b []byte) io.Reader {
@@ -7,33 +7,13 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
-	"gopkg.in/yaml.v3"
-
 	"github.com/tos-network/labu/labusim"
+	"github.com/tos-network/labu/labusim/vectors"
 )
 
-type VectorSuite struct {
-	TestVectors []TestVector `json:"test_vectors" yaml:"test_vectors"`
-}
-
-type TestVector struct {
-	Name        string `json:"name" yaml:"name"`
-	Description string `json:"description" yaml:"description"`
-	PreState    map[string]interface{} `json:"pre_state" yaml:"pre_state"`
-	Input       struct {
-		RPC    map[string]interface{} `json:"rpc" yaml:"rpc"`
-		RPCURL string                 `json:"rpc_url" yaml:"rpc_url"`
-	} `json:"input" yaml:"input"`
-	Expected struct {
-		Response json.RawMessage `json:"response" yaml:"response"`
-	} `json:"expected" yaml:"expected"`
-}
-
 func main() {
 	sim := labusim.New()
 	clients := labusim.ClientList()
@@ -42,7 +22,7 @@ func main() {
 	}
 
 	vectorDir := labusim.VectorDir()
-	vectors, err := loadVectors(vectorDir)
+	vecs, err := vectors.LoadAll(vectorDir, vectors.KindRPC)
 	if err != nil {
 		panic(err)
 	}
@@ -52,7 +32,7 @@ func main() {
 		Description: "RPC conformance suite (health + vectors)",
 	}
 
-	if len(vectors) == 0 {
+	if len(vecs) == 0 {
 		for _, client := range clients {
 			cname := client
 			suite.AddClient(labusim.ClientTestSpec{
@@ -72,7 +52,7 @@ func main() {
 		return
 	}
 
-	for _, vec := range vectors {
+	for _, vec := range vecs {
 		vec := vec
 		for _, client := range clients {
 			cname := client
@@ -99,19 +79,19 @@ func main() {
 						}
 					}
 
-					rpcURL := resolveRPCURL(baseURL, vec.Input.RPCURL)
-					resp, err := callRPC(rpcURL, vec.Input.RPC)
+					rpcURL := resolveRPCURL(baseURL, vec.RPCURL)
+					resp, err := callRPC(rpcURL, vec.RPC)
 					if err != nil {
 						t.Failf("rpc call failed: %v", err)
 						return
 					}
-					if len(vec.Expected.Response) > 0 {
+					if len(vec.ExpectedResponse) > 0 {
 						got, err := canonicalJSON(resp)
 						if err != nil {
 							t.Failf("rpc response invalid json: %v", err)
 							return
 						}
-						exp, err := canonicalJSON(vec.Expected.Response)
+						exp, err := canonicalJSON(vec.ExpectedResponse)
 						if err != nil {
 							t.Failf("expected response invalid json: %v", err)
 							return
@@ -119,6 +99,33 @@ func main() {
 						if !bytes.Equal(got, exp) {
 							t.Failf("rpc response mismatch: got=%s expected=%s", string(got), string(exp))
 						}
+						return
+					}
+
+					if len(vec.ExpectedSchema) > 0 {
+						if err := vectors.ValidateSchema(resp, vec.ExpectedSchema); err != nil {
+							t.Failf("response schema: %v", err)
+							return
+						}
+					}
+					if len(vec.ExpectedAssertions) > 0 {
+						results, err := vectors.EvaluateAssertions(resp, vec.ExpectedAssertions)
+						if err != nil {
+							t.Failf("assertions: %v", err)
+							return
+						}
+						failed := 0
+						for _, r := range results {
+							status := "pass"
+							if !r.Pass {
+								status = "fail"
+								failed++
+							}
+							t.Log(fmt.Sprintf("[%s] %s %s: %s", status, r.Assertion.Path, r.Assertion.Op, r.Detail))
+						}
+						if failed > 0 {
+							t.Failf("%d/%d assertions failed", failed, len(results))
+						}
 					}
 				},
 			})
@@ -128,48 +135,6 @@ func main() {
 	labusim.MustRunSuite(sim, suite)
 }
 
-func loadVectors(root string) ([]TestVector, error) {
-	if root == "" {
-		return nil, nil
-	}
-	var out []TestVector
-	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if d.IsDir() {
-			return nil
-		}
-		ext := filepath.Ext(path)
-		if ext != ".yaml" && ext != ".yml" && ext != ".json" {
-			return nil
-		}
-		data, err := os.ReadFile(path)
-		if err != nil {
-			return err
-		}
-		var suite VectorSuite
-		if ext == ".json" {
-			if err := json.Unmarshal(data, &suite); err != nil {
-				return err
-			}
-		} else {
-			if err := yaml.Unmarshal(data, &suite); err != nil {
-				return err
-			}
-		}
-		for _, vec := range suite.TestVectors {
-			// Only accept rpc vectors; ignore execution/consensus vectors in the same vectorDir.
-			if vec.Input.RPC == nil || len(vec.Input.RPC) == 0 {
-				continue
-			}
-			out = append(out, vec)
-		}
-		return nil
-	})
-	return out, err
-}
-
 func callRPC(url string, payload map[string]interface{}) ([]byte, error) {
 	body, _ := json.Marshal(payload)
 	resp, err := http.Post(url, "application/json", bytes.NewReader(body))